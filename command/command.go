@@ -1,11 +1,14 @@
 package command
 
 import (
+	"context"
 	"fmt"
 	"os/exec"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
 )
 
 // Replace `(<` with `(?P<` to support JS style regexps
@@ -15,10 +18,15 @@ func createRegexp(re string) (*regexp.Regexp, error) {
 	return regexp.Compile(formatted)
 }
 
-func generateCommand(base string, pattern string, input string) (bin string, args []string, err error) {
+// Replaces references in the template with the captures from matching the
+// pattern against the input. Also returns whether the pattern matched at all
+func Expand(template string, pattern string, input string) (string, bool, error) {
 	re, err := createRegexp(pattern)
+	if err != nil {
+		return template, false, err
+	}
 
-	result := base
+	result := template
 	names := re.SubexpNames()
 
 	matches := re.FindAllStringSubmatch(input, -1)
@@ -39,6 +47,34 @@ func generateCommand(base string, pattern string, input string) (bin string, arg
 	// This is a convenience syntax for $0
 	result = strings.ReplaceAll(result, "$", input)
 
+	return result, len(matches) > 0, nil
+}
+
+// Replaces `$name` references in the template with the value of the field.
+// Longer names are replaced first so that `$namespace` is not taken as `$name`
+func ExpandFields(template string, fields map[string]string) string {
+	names := []string{}
+	for name := range fields {
+		names = append(names, name)
+	}
+
+	slices.SortFunc(names, func(a string, b string) int {
+		return len(b) - len(a)
+	})
+
+	for _, name := range names {
+		template = strings.ReplaceAll(template, "$"+name, fields[name])
+	}
+
+	return template
+}
+
+func generateCommand(base string, pattern string, input string) (bin string, args []string, err error) {
+	result, _, err := Expand(base, pattern, input)
+	if err != nil {
+		return bin, args, err
+	}
+
 	if result == base {
 		result = strings.TrimSpace(base) + " " + input
 	}
@@ -54,6 +90,17 @@ func generateCommand(base string, pattern string, input string) (bin string, arg
 	return bin, args, fmt.Errorf("could not create command from: \n  base: %s \n  pattern: %s \n  input: %s \n  result: %s", base, pattern, input, result)
 }
 
+// Only this many lines are highlighted by bat, which otherwise reads and
+// highlights the whole file
+const BatLines = 2000
+
+// Checks if `bat` is available to use as the default preview command, the
+// path is only searched once since this is checked for every preview
+var HasBat = sync.OnceValue(func() bool {
+	_, err := exec.LookPath("bat")
+	return err == nil
+})
+
 func useCommand(preview string, input string, width int) (string, []string) {
 	if len(preview) > 0 {
 		parts := strings.Split(preview, " ")
@@ -63,17 +110,25 @@ func useCommand(preview string, input string, width int) (string, []string) {
 		return bin, args
 	}
 
-	_, err := exec.LookPath("bat")
-	if err == nil {
-		return "bat", []string{"--color=always", "--number", "--terminal-width", strconv.Itoa(width), input}
+	if HasBat() {
+		return "bat", []string{"--color=always", "--number", "--terminal-width", strconv.Itoa(width), "--line-range", ":" + strconv.Itoa(BatLines), input}
 	} else {
 		return "cat", []string{input}
 	}
 }
 
 // If `pattern` is provided will use command generation - otherwise will default to
-// simple append-based behavior
-func CreateCommand(base string, pattern string, input string, width int) (*exec.Cmd, error) {
+// simple append-based behavior. References to `fields` are always replaced and
+// a command that uses them is not appended to
+func CreateCommand(base string, pattern string, input string, fields map[string]string, width int) (*exec.Cmd, error) {
+	expanded := ExpandFields(base, fields)
+	if expanded != base && pattern == "" {
+		parts := strings.Split(expanded, " ")
+		return exec.Command(parts[0], parts[1:]...), nil
+	}
+
+	base = expanded
+
 	if pattern == "" {
 		bin, args := useCommand(base, input, width)
 		cmd := exec.Command(bin, args...)
@@ -90,3 +145,47 @@ func CreateCommand(base string, pattern string, input string, width int) (*exec.
 	return exec.Command(bin, args...), nil
 
 }
+
+// Quotes the value so the shell takes it as a single argument
+func quote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+// Creates the command that the input is read from. It is run by the shell so
+// that it can be a pipeline, with `$query` replaced by the quoted query.
+// Cancelling the context stops the whole pipeline
+func Source(ctx context.Context, template string, query string) *exec.Cmd {
+	expanded := strings.ReplaceAll(template, "$query", quote(query))
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", expanded)
+	killGroup(cmd)
+
+	return cmd
+}
+
+// Creates a command to run on an item. References are replaced the same way
+// as the preview command, but the input is only used where it is referenced.
+// The template is split into arguments first so that paths with spaces are
+// passed as a single argument
+func Action(template string, pattern string, input string, fields map[string]string) (*exec.Cmd, error) {
+	// without a pattern `$` and `$0` still refer to the whole input
+	if pattern == "" {
+		pattern = ".*"
+	}
+
+	args := []string{}
+	for _, arg := range strings.Fields(template) {
+		expanded, _, err := Expand(ExpandFields(arg, fields), pattern, input)
+		if err != nil {
+			return nil, err
+		}
+
+		args = append(args, expanded)
+	}
+
+	if len(args) == 0 {
+		return nil, fmt.Errorf("no command to run")
+	}
+
+	return exec.Command(args[0], args[1:]...), nil
+}
@@ -0,0 +1,8 @@
+//go:build !unix
+
+package command
+
+import "os/exec"
+
+// Only the shell itself is stopped when cancelled on other platforms
+func killGroup(cmd *exec.Cmd) {}
@@ -0,0 +1,133 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Settings read from the config file, which is TOML
+type File struct {
+	// Flag values that are used unless they are given on the command line
+	Defaults map[string]any `toml:"defaults"`
+	// Named sets of flag values, which take precedence over the defaults
+	Profiles map[string]map[string]any `toml:"profiles"`
+	// Keys for each binding by name, either a single key or a list of them
+	Keys map[string]any `toml:"keys"`
+}
+
+// `$XDG_CONFIG_HOME/tri/config`, falling back to `~/.config/tri/config`
+func Path() string {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+
+		dir = filepath.Join(home, ".config")
+	}
+
+	return filepath.Join(dir, "tri", "config")
+}
+
+// Reads the config file, a missing file is the same as an empty one
+func Load(path string) (File, error) {
+	file := File{}
+	if path == "" {
+		return file, nil
+	}
+
+	_, err := toml.DecodeFile(path, &file)
+	if errors.Is(err, fs.ErrNotExist) {
+		return file, nil
+	}
+
+	if err != nil {
+		return file, fmt.Errorf("could not read config %s: %w", path, err)
+	}
+
+	return file, nil
+}
+
+// The keys of each binding as lists
+func (f File) KeyOverrides() (map[string][]string, error) {
+	overrides := map[string][]string{}
+
+	for name, value := range f.Keys {
+		keys, ok := value.([]any)
+		if !ok {
+			keys = []any{value}
+		}
+
+		overrides[name] = []string{}
+		for _, key := range keys {
+			str, ok := key.(string)
+			if !ok {
+				return nil, fmt.Errorf("keys for %s must be a string or a list of strings", name)
+			}
+
+			overrides[name] = append(overrides[name], str)
+		}
+	}
+
+	return overrides, nil
+}
+
+// Values are given to flags as strings, lists are for repeatable flags
+func flagValues(name string, value any) ([]string, error) {
+	values, ok := value.([]any)
+	if !ok {
+		values = []any{value}
+	}
+
+	strs := []string{}
+	for _, value := range values {
+		switch value.(type) {
+		case string, bool, int64, float64:
+			strs = append(strs, fmt.Sprint(value))
+
+		default:
+			return nil, fmt.Errorf("invalid value for %s: %v", name, value)
+		}
+	}
+
+	return strs, nil
+}
+
+// The flag values from the defaults with the profile's values on top of them
+func (f File) Flags(profile string) (map[string][]string, error) {
+	settings := maps.Clone(f.Defaults)
+	if settings == nil {
+		settings = map[string]any{}
+	}
+
+	if profile != "" {
+		values, ok := f.Profiles[profile]
+		if !ok {
+			names := slices.Sorted(maps.Keys(f.Profiles))
+			return nil, fmt.Errorf("unknown profile %q, expected one of: %s", profile, strings.Join(names, ", "))
+		}
+
+		maps.Copy(settings, values)
+	}
+
+	flags := map[string][]string{}
+	for name, value := range settings {
+		values, err := flagValues(name, value)
+		if err != nil {
+			return nil, err
+		}
+
+		flags[name] = values
+	}
+
+	return flags, nil
+}
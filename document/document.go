@@ -0,0 +1,329 @@
+package document
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/sftsrv/tri/tree"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	FormatJSON = "json"
+	FormatYAML = "yaml"
+	FormatTOML = "toml"
+)
+
+var Formats = []string{FormatJSON, FormatYAML, FormatTOML}
+
+type kind int
+
+const (
+	scalar kind = iota
+	object
+	array
+)
+
+// A value in the document. Objects keep their keys in document order which
+// is lost when decoding into a map
+type value struct {
+	kind   kind
+	keys   []string
+	items  []*value
+	scalar any
+}
+
+type Document struct {
+	root *value
+	// values by their jq path
+	index map[string]*value
+}
+
+func decodeJSON(decoder *json.Decoder) (*value, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	delim, ok := token.(json.Delim)
+	if !ok {
+		return &value{kind: scalar, scalar: token}, nil
+	}
+
+	result := &value{kind: array}
+	if delim == '{' {
+		result.kind = object
+	}
+
+	for decoder.More() {
+		if result.kind == object {
+			key, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+
+			result.keys = append(result.keys, key.(string))
+		}
+
+		item, err := decodeJSON(decoder)
+		if err != nil {
+			return nil, err
+		}
+
+		result.items = append(result.items, item)
+	}
+
+	// closing delimiter
+	_, err = decoder.Token()
+	return result, err
+}
+
+func fromYAML(node *yaml.Node) (*value, error) {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			return &value{kind: scalar}, nil
+		}
+		return fromYAML(node.Content[0])
+
+	case yaml.AliasNode:
+		return fromYAML(node.Alias)
+
+	case yaml.MappingNode:
+		result := &value{kind: object}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			item, err := fromYAML(node.Content[i+1])
+			if err != nil {
+				return nil, err
+			}
+
+			result.keys = append(result.keys, node.Content[i].Value)
+			result.items = append(result.items, item)
+		}
+		return result, nil
+
+	case yaml.SequenceNode:
+		result := &value{kind: array}
+		for _, child := range node.Content {
+			item, err := fromYAML(child)
+			if err != nil {
+				return nil, err
+			}
+
+			result.items = append(result.items, item)
+		}
+		return result, nil
+	}
+
+	var decoded any
+	err := node.Decode(&decoded)
+	return &value{kind: scalar, scalar: decoded}, err
+}
+
+// TOML is decoded into maps so keys are sorted to keep the order stable
+func fromAny(data any) *value {
+	switch data := data.(type) {
+	case map[string]any:
+		result := &value{kind: object}
+		for key := range data {
+			result.keys = append(result.keys, key)
+		}
+		slices.Sort(result.keys)
+
+		for _, key := range result.keys {
+			result.items = append(result.items, fromAny(data[key]))
+		}
+		return result
+
+	case []map[string]any:
+		result := &value{kind: array}
+		for _, item := range data {
+			result.items = append(result.items, fromAny(item))
+		}
+		return result
+
+	case []any:
+		result := &value{kind: array}
+		for _, item := range data {
+			result.items = append(result.items, fromAny(item))
+		}
+		return result
+	}
+
+	return &value{kind: scalar, scalar: data}
+}
+
+func parse(data []byte, format string) (*value, error) {
+	switch format {
+	case FormatJSON:
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		return decodeJSON(decoder)
+
+	case FormatYAML:
+		node := &yaml.Node{}
+		err := yaml.Unmarshal(data, node)
+		if err != nil {
+			return nil, err
+		}
+		return fromYAML(node)
+
+	case FormatTOML:
+		decoded := map[string]any{}
+		_, err := toml.Decode(string(data), &decoded)
+		if err != nil {
+			return nil, err
+		}
+		return fromAny(decoded), nil
+	}
+
+	return nil, fmt.Errorf("unknown document format %q, expected one of %s", format, strings.Join(Formats, ", "))
+}
+
+func Read(r io.Reader, format string) (*Document, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	root, err := parse(data, format)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s document: %w", format, err)
+	}
+
+	return &Document{root: root, index: map[string]*value{}}, nil
+}
+
+var identifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Appends a bracketed segment to the path, the root needs a leading `.` for
+// it to be valid jq, e.g. `.[0]` and `.foo[0]`
+func bracketPath(parent string, segment string) string {
+	if parent == "." {
+		return "." + segment
+	}
+
+	return parent + segment
+}
+
+// Path to the key in jq syntax, e.g. `.foo.bar`, `."my-key"` or `.[""]`
+func keyPath(parent string, key string) string {
+	if key == "" {
+		return bracketPath(parent, `[""]`)
+	}
+
+	if parent == "." {
+		parent = ""
+	}
+
+	if identifier.MatchString(key) {
+		return parent + "." + key
+	}
+
+	quoted, _ := json.Marshal(key)
+	return parent + "." + string(quoted)
+}
+
+func indexPath(parent string, index int) string {
+	return bracketPath(parent, fmt.Sprintf("[%d]", index))
+}
+
+func (d *Document) entries(v *value, parts tree.Parts, path string) []tree.Entry {
+	entries := []tree.Entry{}
+
+	for i, item := range v.items {
+		segment := fmt.Sprintf("[%d]", i)
+		itemPath := indexPath(path, i)
+
+		if v.kind == object {
+			segment = v.keys[i]
+			itemPath = keyPath(path, v.keys[i])
+		}
+
+		// empty segments are dropped from the tree
+		if segment == "" {
+			segment = `[""]`
+		}
+
+		itemParts := append(slices.Clone(parts), segment)
+		d.index[itemPath] = item
+
+		entries = append(entries, tree.Entry{Key: itemPath, Line: itemPath, Parts: itemParts})
+		entries = append(entries, d.entries(item, itemParts, itemPath)...)
+	}
+
+	return entries
+}
+
+// An entry for every value in the document, with the jq path as the line.
+// Documents without any keys, such as `{}` or `5`, have an entry for the root
+// so that its value can still be shown
+func (d *Document) Entries() []tree.Entry {
+	d.index["."] = d.root
+
+	entries := d.entries(d.root, tree.Parts{}, ".")
+	if len(entries) == 0 {
+		return []tree.Entry{{Key: ".", Line: ".", Parts: tree.Parts{"."}}}
+	}
+
+	return entries
+}
+
+func write(b *strings.Builder, v *value, indent string) {
+	switch v.kind {
+	case scalar:
+		encoded, err := json.Marshal(v.scalar)
+		if err != nil {
+			encoded = []byte(fmt.Sprintf("%q", fmt.Sprint(v.scalar)))
+		}
+		b.Write(encoded)
+
+	case object, array:
+		open, close := "[", "]"
+		if v.kind == object {
+			open, close = "{", "}"
+		}
+
+		if len(v.items) == 0 {
+			b.WriteString(open + close)
+			return
+		}
+
+		b.WriteString(open + "\n")
+		for i, item := range v.items {
+			b.WriteString(indent + "  ")
+			if v.kind == object {
+				key, _ := json.Marshal(v.keys[i])
+				b.Write(key)
+				b.WriteString(": ")
+			}
+
+			write(b, item, indent+"  ")
+
+			if i < len(v.items)-1 {
+				b.WriteString(",")
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString(indent + close)
+	}
+}
+
+// Pretty printed JSON of the value at the jq path
+func (d *Document) Preview(path string) string {
+	v, ok := d.index[path]
+	if !ok {
+		return "ERROR no value at " + path
+	}
+
+	b := &strings.Builder{}
+	write(b, v, "")
+
+	return b.String()
+}
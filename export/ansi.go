@@ -0,0 +1,186 @@
+package export
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Matches CSI sequences, such as colors and cursor movement, and OSC sequences
+// such as hyperlinks
+var escapeSequence = regexp.MustCompile(`\x1b\[[0-9;?]*[ -/]*[@-~]|\x1b\][^\x07\x1b]*(\x07|\x1b\\)`)
+
+var basicColors = []string{
+	"#000000", "#cd3131", "#0dbc79", "#e5e510", "#2472c8", "#bc3fbc", "#11a8cd", "#e5e5e5",
+	"#666666", "#f14c4c", "#23d18b", "#f5f543", "#3b8eea", "#d670d6", "#29b8db", "#ffffff",
+}
+
+// Converts a 256 color palette index to hex
+func paletteColor(index int) string {
+	switch {
+	case index < 16:
+		return basicColors[index]
+
+	case index < 232:
+		index -= 16
+		levels := []int{0, 95, 135, 175, 215, 255}
+		return fmt.Sprintf("#%02x%02x%02x", levels[index/36], levels[index/6%6], levels[index%6])
+
+	default:
+		gray := 8 + (index-232)*10
+		return fmt.Sprintf("#%02x%02x%02x", gray, gray, gray)
+	}
+}
+
+type sgrState struct {
+	fg        string
+	bg        string
+	bold      bool
+	dim       bool
+	italic    bool
+	underline bool
+}
+
+func (s sgrState) style() string {
+	styles := []string{}
+
+	if s.fg != "" {
+		styles = append(styles, "color:"+s.fg)
+	}
+	if s.bg != "" {
+		styles = append(styles, "background:"+s.bg)
+	}
+	if s.bold {
+		styles = append(styles, "font-weight:bold")
+	}
+	if s.dim {
+		styles = append(styles, "opacity:0.7")
+	}
+	if s.italic {
+		styles = append(styles, "font-style:italic")
+	}
+	if s.underline {
+		styles = append(styles, "text-decoration:underline")
+	}
+
+	return strings.Join(styles, ";")
+}
+
+// Reads an extended color, `5;n` or `2;r;g;b`, returning how many params were used
+func extendedColor(params []int) (string, int) {
+	if len(params) >= 2 && params[0] == 5 {
+		return paletteColor(params[1] % 256), 2
+	}
+
+	if len(params) >= 4 && params[0] == 2 {
+		return fmt.Sprintf("#%02x%02x%02x", params[1]%256, params[2]%256, params[3]%256), 4
+	}
+
+	return "", len(params)
+}
+
+func (s sgrState) apply(params []int) sgrState {
+	if len(params) == 0 {
+		return sgrState{}
+	}
+
+	for i := 0; i < len(params); i++ {
+		param := params[i]
+
+		switch {
+		case param == 0:
+			s = sgrState{}
+		case param == 1:
+			s.bold = true
+		case param == 2:
+			s.dim = true
+		case param == 3:
+			s.italic = true
+		case param == 4:
+			s.underline = true
+		case param == 22:
+			s.bold, s.dim = false, false
+		case param == 23:
+			s.italic = false
+		case param == 24:
+			s.underline = false
+		case 30 <= param && param <= 37:
+			s.fg = basicColors[param-30]
+		case 90 <= param && param <= 97:
+			s.fg = basicColors[param-90+8]
+		case 40 <= param && param <= 47:
+			s.bg = basicColors[param-40]
+		case 100 <= param && param <= 107:
+			s.bg = basicColors[param-100+8]
+		case param == 39:
+			s.fg = ""
+		case param == 49:
+			s.bg = ""
+		case param == 38 || param == 48:
+			color, used := extendedColor(params[i+1:])
+			if param == 38 {
+				s.fg = color
+			} else {
+				s.bg = color
+			}
+			i += used
+		}
+	}
+
+	return s
+}
+
+func parseParams(sequence string) []int {
+	params := []int{}
+
+	inner := strings.TrimSuffix(strings.TrimPrefix(sequence, "\x1b["), "m")
+	if inner == "" {
+		return params
+	}
+
+	for _, param := range strings.Split(inner, ";") {
+		value, _ := strconv.Atoi(param)
+		params = append(params, value)
+	}
+
+	return params
+}
+
+// Converts text with ANSI colors to escaped HTML using inline styles. Escape
+// sequences other than colors are dropped
+func ansiToHTML(text string) string {
+	b := &strings.Builder{}
+	state := sgrState{}
+
+	write := func(str string) {
+		// stray escapes that are not part of a known sequence
+		str = strings.ReplaceAll(str, "\x1b", "")
+		if str == "" {
+			return
+		}
+
+		style := state.style()
+		if style != "" {
+			fmt.Fprintf(b, `<span style="%s">%s</span>`, style, html.EscapeString(str))
+		} else {
+			b.WriteString(html.EscapeString(str))
+		}
+	}
+
+	last := 0
+	for _, loc := range escapeSequence.FindAllStringIndex(text, -1) {
+		write(text[last:loc[0]])
+		last = loc[1]
+
+		sequence := text[loc[0]:loc[1]]
+		if strings.HasPrefix(sequence, "\x1b[") && strings.HasSuffix(sequence, "m") {
+			state = state.apply(parseParams(sequence))
+		}
+	}
+
+	write(text[last:])
+
+	return b.String()
+}
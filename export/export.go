@@ -0,0 +1,202 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/sftsrv/tri/theme"
+	"github.com/sftsrv/tri/tree"
+)
+
+const (
+	FormatIcons    = "icons"
+	FormatASCII    = "ascii"
+	FormatTree     = "tree"
+	FormatMarkdown = "markdown"
+	FormatJSON     = "json"
+	FormatDOT      = "dot"
+	FormatMermaid  = "mermaid"
+	FormatMindmap  = "mindmap"
+)
+
+var Formats = []string{FormatIcons, FormatASCII, FormatTree, FormatMarkdown, FormatJSON, FormatDOT, FormatMermaid, FormatMindmap}
+
+type Config struct {
+	Format string
+	// Limits for the graph formats, 0 means no limit
+	Depth       int
+	MaxChildren int
+}
+
+// Picks a format based on the file extension, e.g. `.dot` or `.mmd`
+func FormatForFile(path string) string {
+	switch filepath.Ext(path) {
+	case ".dot", ".gv":
+		return FormatDOT
+	case ".mmd", ".mermaid":
+		return FormatMermaid
+	case ".md":
+		return FormatMarkdown
+	case ".json":
+		return FormatJSON
+	}
+
+	return FormatTree
+}
+
+// Each item is last if no sibling comes after it
+func lastSiblings(items []*tree.Item) []bool {
+	last := make([]bool, len(items))
+
+	for i, item := range items {
+		last[i] = true
+
+		for _, next := range items[i+1:] {
+			if next.Level() < item.Level() {
+				break
+			}
+
+			if next.Level() == item.Level() {
+				last[i] = false
+				break
+			}
+		}
+	}
+
+	return last
+}
+
+// Folders end with a `/` unless their name already does, such as a `/` root
+func folderLabel(item *tree.Item) string {
+	if item.IsFile() || strings.HasSuffix(item.Name(), "/") {
+		return item.Label()
+	}
+
+	return item.LabelWith("/")
+}
+
+func ascii(items []*tree.Item) string {
+	lines := []string{}
+
+	for _, item := range items {
+		label := folderLabel(item)
+
+		lines = append(lines, strings.Repeat(tree.INDENT, item.Level())+label)
+	}
+
+	return tree.AlignAsides(items, lines)
+}
+
+// Draws the tree like the `tree` command does
+func boxDrawing(items []*tree.Item) string {
+	last := lastSiblings(items)
+	lines := []string{}
+
+	// whether the ancestor at each level was the last of its siblings
+	ancestors := []bool{}
+
+	for i, item := range items {
+		ancestors = append(ancestors[:item.Level()], last[i])
+
+		prefix := ""
+		for _, ancestorLast := range ancestors[:item.Level()] {
+			if ancestorLast {
+				prefix += "    "
+			} else {
+				prefix += "│   "
+			}
+		}
+
+		connector := "├── "
+		if last[i] {
+			connector = "└── "
+		}
+
+		lines = append(lines, prefix+connector+item.Label())
+	}
+
+	return ".\n" + tree.AlignAsides(items, lines)
+}
+
+func markdown(items []*tree.Item) string {
+	result := ""
+
+	for _, item := range items {
+		label := folderLabel(item)
+
+		result += fmt.Sprintf("%s- %s\n", strings.Repeat(tree.INDENT, item.Level()), label)
+	}
+
+	return result
+}
+
+type jsonNode struct {
+	Name     string      `json:"name"`
+	Path     string      `json:"path"`
+	Kind     string      `json:"kind"`
+	Children []*jsonNode `json:"children,omitempty"`
+}
+
+// Nests the items using their levels, collapsed folders have no children
+func toJSON(items []*tree.Item) (string, error) {
+	root := &jsonNode{Children: []*jsonNode{}}
+	parents := []*jsonNode{root}
+
+	for _, item := range items {
+		node := &jsonNode{
+			Name: item.Name(),
+			Path: item.GetPath(),
+			Kind: "file",
+		}
+
+		if !item.IsFile() {
+			node.Kind = "folder"
+		}
+
+		parents = parents[:item.Level()+1]
+		parent := parents[item.Level()]
+		parent.Children = append(parent.Children, node)
+		parents = append(parents, node)
+	}
+
+	bytes, err := json.MarshalIndent(root.Children, "", "  ")
+	return string(bytes) + "\n", err
+}
+
+// Renders the tree in the given format, only expanded folders are included.
+// The output is plain text, the theme is left out so that colors aren't
+// written into it as escape codes
+func Render(t *tree.Tree, opts tree.Options, config Config) (string, error) {
+	opts.Theme = theme.Theme{}
+	items := tree.ToItems(t, opts)
+
+	switch config.Format {
+	case FormatIcons, "":
+		return tree.Render(t, opts), nil
+
+	case FormatASCII:
+		return ascii(items), nil
+
+	case FormatTree:
+		return boxDrawing(items), nil
+
+	case FormatMarkdown:
+		return markdown(items), nil
+
+	case FormatJSON:
+		return toJSON(items)
+
+	case FormatDOT:
+		return dot(buildGraph(t, opts, config)), nil
+
+	case FormatMermaid:
+		return mermaid(buildGraph(t, opts, config)), nil
+
+	case FormatMindmap:
+		return mindmap(buildGraph(t, opts, config)), nil
+	}
+
+	return "", fmt.Errorf("unknown format %q, expected one of %s", config.Format, strings.Join(Formats, ", "))
+}
@@ -0,0 +1,87 @@
+package export
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sftsrv/tri/tree"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files with the current output")
+
+var paths = []string{
+	"go.mod",
+	"src/main.go",
+	"src/ui/app.go",
+	"src/ui/view.go",
+	"docs/readme.md",
+	"deep/a/b/c.txt",
+}
+
+func newTree() *tree.Tree {
+	return tree.PathsToTree(paths, tree.BuildOptions{Separator: tree.NewSeparator(tree.SEP)})
+}
+
+func TestRender(t *testing.T) {
+	trees := map[string]func() *tree.Tree{
+		"expanded": newTree,
+		"collapsed": func() *tree.Tree {
+			t := newTree()
+			t.ExpandDepth(2)
+			return t
+		},
+		"flattened": func() *tree.Tree {
+			t := newTree()
+			t.Flatten()
+			return t
+		},
+		"decorated": func() *tree.Tree {
+			t := newTree()
+			t.Children["src"].Children["main.go"].Status = tree.Modified
+			t.Children["src"].Children["ui"].Children["view.go"].Status = tree.Added
+			t.Children["go.mod"].Status = tree.Untracked
+			return t
+		},
+		// the root is named after the separator, so it isn't given another
+		"absolute": func() *tree.Tree {
+			return tree.PathsToTree([]string{"/x/y/a"}, tree.BuildOptions{Separator: tree.NewSeparator(tree.SEP), Normalize: true})
+		},
+	}
+
+	// metrics are shown as asides, which are aligned after the labels
+	options := map[string]tree.Options{
+		"decorated": {Metrics: tree.Metrics{Count: true}},
+	}
+
+	formats := []string{FormatJSON, FormatTree, FormatMarkdown, FormatASCII, FormatIcons}
+
+	for name, build := range trees {
+		for _, format := range formats {
+			t.Run(name+"/"+format, func(t *testing.T) {
+				got, err := Render(build(), options[name], Config{Format: format})
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				golden := filepath.Join("testdata", name+"."+format+".golden")
+				if *update {
+					err := os.WriteFile(golden, []byte(got), 0644)
+					if err != nil {
+						t.Fatal(err)
+					}
+				}
+
+				want, err := os.ReadFile(golden)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if got != string(want) {
+					t.Errorf("output does not match %s\ngot:\n%s\nwant:\n%s", golden, got, want)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package export
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sftsrv/tri/tree"
+)
+
+type graphNode struct {
+	id       string
+	label    string
+	folder   bool
+	more     bool
+	children []*graphNode
+}
+
+type graphBuilder struct {
+	opts   tree.Options
+	config Config
+	count  int
+}
+
+func (b *graphBuilder) node(label string, folder bool) *graphNode {
+	node := &graphNode{
+		id:     fmt.Sprintf("n%d", b.count),
+		label:  label,
+		folder: folder,
+	}
+
+	b.count++
+	return node
+}
+
+// Follows the expanded state of the tree, stopping at the depth limit and
+// replacing children past the limit with a single "N more" node
+func (b *graphBuilder) build(node *graphNode, t *tree.Tree, depth int) {
+	if b.config.Depth > 0 && depth >= b.config.Depth {
+		return
+	}
+
+	keys := t.Keys(b.opts.Sort)
+	for i, key := range keys {
+		if b.config.MaxChildren > 0 && i >= b.config.MaxChildren {
+			more := b.node(fmt.Sprintf("%d more", len(keys)-i), false)
+			more.more = true
+			node.children = append(node.children, more)
+			break
+		}
+
+		child := t.Children[key]
+		childNode := b.node(key, len(child.Children) > 0)
+		node.children = append(node.children, childNode)
+
+		if child.Expanded {
+			b.build(childNode, child, depth+1)
+		}
+	}
+}
+
+func buildGraph(t *tree.Tree, opts tree.Options, config Config) *graphNode {
+	builder := &graphBuilder{opts: opts, config: config}
+
+	root := builder.node(".", true)
+	builder.build(root, t, 0)
+
+	return root
+}
+
+func quoteDOT(str string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(str) + `"`
+}
+
+func writeDOT(b *strings.Builder, node *graphNode) {
+	attrs := ""
+	if node.folder {
+		attrs = " shape=folder"
+	} else if node.more {
+		attrs = " style=dashed"
+	}
+
+	fmt.Fprintf(b, "  %s [label=%s%s];\n", node.id, quoteDOT(node.label), attrs)
+
+	for _, child := range node.children {
+		writeDOT(b, child)
+		fmt.Fprintf(b, "  %s -> %s;\n", node.id, child.id)
+	}
+}
+
+func dot(root *graphNode) string {
+	b := &strings.Builder{}
+
+	b.WriteString("digraph tree {\n")
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [shape=box];\n")
+	writeDOT(b, root)
+	b.WriteString("}\n")
+
+	return b.String()
+}
+
+// Mermaid labels are quoted, quotes inside them need to be written as entities
+func quoteMermaid(str string) string {
+	return `"` + strings.NewReplacer(`"`, "#quot;", "\n", " ").Replace(str) + `"`
+}
+
+func writeMermaid(b *strings.Builder, node *graphNode) {
+	for _, child := range node.children {
+		shape := "[%s]"
+		if child.folder {
+			shape = "[/%s/]"
+		} else if child.more {
+			shape = "(%s)"
+		}
+
+		fmt.Fprintf(b, "  %s --> %s"+shape+"\n", node.id, child.id, quoteMermaid(child.label))
+		writeMermaid(b, child)
+	}
+}
+
+func mermaid(root *graphNode) string {
+	b := &strings.Builder{}
+
+	b.WriteString("graph LR\n")
+	fmt.Fprintf(b, "  %s[%s]\n", root.id, quoteMermaid(root.label))
+	writeMermaid(b, root)
+
+	return b.String()
+}
+
+func writeMindmap(b *strings.Builder, node *graphNode, level int) {
+	for _, child := range node.children {
+		fmt.Fprintf(b, "%s%s[%s]\n", strings.Repeat(tree.INDENT, level), child.id, quoteMermaid(child.label))
+		writeMindmap(b, child, level+1)
+	}
+}
+
+func mindmap(root *graphNode) string {
+	b := &strings.Builder{}
+
+	b.WriteString("mindmap\n")
+	fmt.Fprintf(b, "  %s((%s))\n", root.id, quoteMermaid(root.label))
+	writeMindmap(b, root, 2)
+
+	return b.String()
+}
@@ -0,0 +1,156 @@
+package export
+
+import (
+	"fmt"
+	"html"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/sftsrv/tri/tree"
+)
+
+// Captures the preview of a file to embed in the report
+type Previewer func(line string, fields map[string]string) string
+
+const style = `
+body { background: #1e1e2e; color: #cdd6f4; font-family: ui-monospace, SFMono-Regular, Menlo, monospace; font-size: 14px; margin: 2em; }
+h1 { font-size: 1.2em; color: #cba6f7; }
+details { margin-left: 1.5em; }
+summary { cursor: pointer; }
+summary:hover, .file:hover { color: #cba6f7; }
+.file { margin-left: 1.5em; padding-left: 1em; }
+.folder > summary { font-weight: bold; }
+.status { margin-left: 0.5em; font-size: 0.85em; }
+.added { color: #a6e3a1; } .modified { color: #f9e2af; } .deleted { color: #f38ba8; }
+.renamed { color: #89b4fa; } .untracked { color: #9399b2; }
+pre { background: #11111b; padding: 1em; margin: 0.5em 0 0.5em 1em; overflow-x: auto; border-left: 2px solid #b4befe; }
+`
+
+type htmlWriter struct {
+	b        *strings.Builder
+	opts     tree.Options
+	previews map[*tree.Tree]string
+}
+
+func line(t *tree.Tree) string {
+	if t.Line != "" {
+		return t.Line
+	}
+
+	return t.Path
+}
+
+func files(t *tree.Tree) []*tree.Tree {
+	if len(t.Children) == 0 {
+		return []*tree.Tree{t}
+	}
+
+	result := []*tree.Tree{}
+	for _, child := range t.Children {
+		result = append(result, files(child)...)
+	}
+
+	return result
+}
+
+// Runs the previewer for all files in parallel since each can be a slow command
+func capturePreviews(t *tree.Tree, previewer Previewer) map[*tree.Tree]string {
+	previews := map[*tree.Tree]string{}
+	if previewer == nil {
+		return previews
+	}
+
+	var lock sync.Mutex
+	var wait sync.WaitGroup
+
+	queue := make(chan *tree.Tree)
+	for range runtime.NumCPU() {
+		wait.Add(1)
+		go func() {
+			defer wait.Done()
+			for file := range queue {
+				preview := previewer(line(file), file.Fields)
+
+				lock.Lock()
+				previews[file] = preview
+				lock.Unlock()
+			}
+		}()
+	}
+
+	for _, file := range files(t) {
+		if file != t {
+			queue <- file
+		}
+	}
+
+	close(queue)
+	wait.Wait()
+
+	return previews
+}
+
+var statusClasses = map[tree.Status]string{
+	tree.Added:     "added",
+	tree.Modified:  "modified",
+	tree.Deleted:   "deleted",
+	tree.Renamed:   "renamed",
+	tree.Untracked: "untracked",
+}
+
+func status(t *tree.Tree) string {
+	if t.Status == tree.Unchanged {
+		return ""
+	}
+
+	return fmt.Sprintf(`<span class="status %s">%s</span>`, statusClasses[t.Status], t.Status.Marker())
+}
+
+func (w *htmlWriter) write(t *tree.Tree) {
+	for _, key := range t.Keys(w.opts.Sort) {
+		child := t.Children[key]
+		name := html.EscapeString(key)
+		title := html.EscapeString(line(child))
+
+		if len(child.Children) > 0 {
+			open := ""
+			if child.Expanded {
+				open = " open"
+			}
+
+			fmt.Fprintf(w.b, `<details class="folder"%s><summary title="%s">%s/</summary>`+"\n", open, title, name)
+			w.write(child)
+			w.b.WriteString("</details>\n")
+			continue
+		}
+
+		preview, ok := w.previews[child]
+		if !ok {
+			fmt.Fprintf(w.b, `<div class="file" title="%s">%s%s</div>`+"\n", title, name, status(child))
+			continue
+		}
+
+		fmt.Fprintf(w.b, `<details><summary title="%s">%s%s</summary><pre>%s</pre></details>`+"\n", title, name, status(child), ansiToHTML(preview))
+	}
+}
+
+// A self-contained page with the tree as collapsible folders. Previews are
+// embedded for each file if a previewer is given
+func HTML(t *tree.Tree, opts tree.Options, title string, previewer Previewer) string {
+	w := &htmlWriter{
+		b:        &strings.Builder{},
+		opts:     opts,
+		previews: capturePreviews(t, previewer),
+	}
+
+	w.b.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n")
+	fmt.Fprintf(w.b, "<title>%s</title>\n", html.EscapeString(title))
+	fmt.Fprintf(w.b, "<style>%s</style>\n", style)
+	w.b.WriteString("</head>\n<body>\n")
+	fmt.Fprintf(w.b, "<h1>%s</h1>\n", html.EscapeString(title))
+	w.write(t)
+	w.b.WriteString("</body>\n</html>\n")
+
+	return w.b.String()
+}
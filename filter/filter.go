@@ -0,0 +1,87 @@
+package filter
+
+type Filter struct {
+	include   []glob
+	exclude   []glob
+	gitignore *gitignore
+}
+
+func compile(patterns []string) ([]glob, error) {
+	globs := []glob{}
+
+	for _, pattern := range patterns {
+		g, err := newGlob(pattern)
+		if err != nil {
+			return nil, err
+		}
+
+		globs = append(globs, g)
+	}
+
+	return globs, nil
+}
+
+func New(include []string, exclude []string, gitignore bool) (*Filter, error) {
+	f := &Filter{}
+
+	var err error
+	f.include, err = compile(include)
+	if err != nil {
+		return nil, err
+	}
+
+	f.exclude, err = compile(exclude)
+	if err != nil {
+		return nil, err
+	}
+
+	if gitignore {
+		f.gitignore = newGitignore()
+	}
+
+	return f, nil
+}
+
+// Matches the path or any of its parent folders
+func matchAny(globs []glob, parts []string) bool {
+	for i := range parts {
+		for _, g := range globs {
+			if g.match(parts[:i+1]) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// A path is kept if it matches an include pattern, if there are any, and
+// neither it or its parent folders are excluded or ignored. Safe to call
+// concurrently, such as from the walker
+func (f *Filter) Keep(path string) bool {
+	parts := split(path)
+
+	if len(f.include) > 0 && !matchAny(f.include, parts) {
+		return false
+	}
+
+	return f.keep(path, parts)
+}
+
+// Include patterns are left out for folders since they are usually written
+// for files, such as `*.go`, and the folders need to be read to find them
+func (f *Filter) KeepFolder(path string) bool {
+	return f.keep(path, split(path))
+}
+
+func (f *Filter) keep(path string, parts []string) bool {
+	if matchAny(f.exclude, parts) {
+		return false
+	}
+
+	if f.gitignore != nil && f.gitignore.ignores(path) {
+		return false
+	}
+
+	return true
+}
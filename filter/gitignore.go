@@ -0,0 +1,230 @@
+package filter
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+type rule struct {
+	glob    glob
+	negate  bool
+	dirOnly bool
+}
+
+// Rules from a single .gitignore file, matched relative to its folder
+type ignoreFile struct {
+	rules []rule
+}
+
+func readIgnoreFile(path string) *ignoreFile {
+	file := &ignoreFile{}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return file
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " ")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		r := rule{}
+		if strings.HasPrefix(line, "!") {
+			r.negate = true
+			line = line[1:]
+		}
+
+		r.dirOnly = strings.HasSuffix(line, "/")
+
+		// a leading slash anchors the pattern but is not part of it
+		anchored := strings.HasPrefix(line, "/")
+		g, err := newGlob(strings.TrimPrefix(line, "/"))
+		if err != nil {
+			continue
+		}
+
+		if anchored && g.anywhere {
+			g.segments = g.segments[1:]
+			g.anywhere = false
+		}
+
+		r.glob = g
+		file.rules = append(file.rules, r)
+	}
+
+	return file
+}
+
+// Whether the file ignores the path, or nil if none of its rules match. The
+// last matching rule wins so that later negations can re-include paths
+func (f *ignoreFile) match(parts []string, isDir func() bool) *bool {
+	var ignored *bool
+
+	for _, r := range f.rules {
+		if r.dirOnly && !isDir() {
+			continue
+		}
+
+		if r.glob.match(parts) {
+			result := !r.negate
+			ignored = &result
+		}
+	}
+
+	return ignored
+}
+
+// Safe for concurrent use since folders are walked and filtered concurrently
+type gitignore struct {
+	lock sync.Mutex
+	// parsed files by folder, empty if the folder has none
+	files map[string]*ignoreFile
+	// results for folders since they are shared by many paths
+	folders map[string]bool
+	// the repository root of each folder, empty if it is not in one
+	repos map[string]string
+	cwd   string
+}
+
+func newGitignore() *gitignore {
+	cwd, _ := os.Getwd()
+
+	return &gitignore{
+		files:   map[string]*ignoreFile{},
+		folders: map[string]bool{},
+		repos:   map[string]string{},
+		cwd:     cwd,
+	}
+}
+
+func (g *gitignore) file(dir string) *ignoreFile {
+	g.lock.Lock()
+	file, ok := g.files[dir]
+	g.lock.Unlock()
+
+	if ok {
+		return file
+	}
+
+	file = readIgnoreFile(filepath.Join(dir, ".gitignore"))
+
+	g.lock.Lock()
+	g.files[dir] = file
+	g.lock.Unlock()
+
+	return file
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// The closest folder above dir, or dir itself, that contains a .git folder
+func (g *gitignore) repo(dir string) string {
+	g.lock.Lock()
+	root, ok := g.repos[dir]
+	g.lock.Unlock()
+
+	if ok {
+		return root
+	}
+
+	_, err := os.Stat(filepath.Join(dir, ".git"))
+	if err == nil {
+		root = dir
+	} else if parent := filepath.Dir(dir); parent != dir {
+		root = g.repo(parent)
+	}
+
+	g.lock.Lock()
+	g.repos[dir] = root
+	g.lock.Unlock()
+
+	return root
+}
+
+// The folder that .gitignore files are read from and the path's parts below
+// it. This is the repository root so that ignore files above the input paths
+// apply, outside of a repository it's the folder the paths are relative to
+func (g *gitignore) base(path string) (string, []string) {
+	abs := path
+	if !filepath.IsAbs(path) {
+		abs = filepath.Join(g.cwd, path)
+	}
+
+	root := g.repo(filepath.Dir(abs))
+	if root == "" {
+		root = "/"
+		if !filepath.IsAbs(path) && !strings.HasPrefix(filepath.Clean(path), "..") {
+			root = g.cwd
+		}
+	}
+
+	rel, err := filepath.Rel(root, abs)
+	if err != nil || rel == "." {
+		return root, nil
+	}
+
+	return root, split(filepath.ToSlash(rel))
+}
+
+// Checks the path against the .gitignore files in each of its parent folders,
+// deeper files take precedence over the ones above them
+func (g *gitignore) ignoresSelf(root string, parts []string, isDir func() bool) bool {
+	if parts[len(parts)-1] == ".git" {
+		return true
+	}
+
+	ignored := false
+	for i := range parts {
+		folder := filepath.Join(append([]string{root}, parts[:i]...)...)
+
+		result := g.file(folder).match(parts[i:], isDir)
+		if result != nil {
+			ignored = *result
+		}
+	}
+
+	return ignored
+}
+
+// A path is ignored if it or any of its parent folders are ignored since
+// git does not look inside of ignored folders
+func (g *gitignore) ignores(path string) bool {
+	root, parts := g.base(path)
+	if len(parts) == 0 {
+		return false
+	}
+
+	folders := parts[:len(parts)-1]
+	for i := range folders {
+		key := filepath.Join(append([]string{root}, folders[:i+1]...)...)
+
+		g.lock.Lock()
+		ignored, ok := g.folders[key]
+		g.lock.Unlock()
+
+		if !ok {
+			ignored = g.ignoresSelf(root, folders[:i+1], func() bool { return true })
+
+			g.lock.Lock()
+			g.folders[key] = ignored
+			g.lock.Unlock()
+		}
+
+		if ignored {
+			return true
+		}
+	}
+
+	// the path itself may or may not be a folder so it is only checked if needed
+	return g.ignoresSelf(root, parts, func() bool { return isDir(path) })
+}
@@ -0,0 +1,75 @@
+package filter
+
+import (
+	"path"
+	"strings"
+)
+
+// A glob where `**` matches any number of path segments
+type glob struct {
+	segments []string
+	// patterns without a slash match a segment at any depth, like in .gitignore
+	anywhere bool
+}
+
+func newGlob(pattern string) (glob, error) {
+	pattern = strings.TrimPrefix(pattern, "./")
+	anywhere := !strings.Contains(strings.TrimSuffix(pattern, "/"), "/")
+	pattern = strings.Trim(pattern, "/")
+
+	segments := strings.Split(pattern, "/")
+	for _, segment := range segments {
+		// checks that the pattern is valid so matching can ignore errors
+		_, err := path.Match(segment, "")
+		if err != nil {
+			return glob{}, err
+		}
+	}
+
+	if anywhere {
+		segments = append([]string{"**"}, segments...)
+	}
+
+	return glob{segments: segments, anywhere: anywhere}, nil
+}
+
+func matchSegments(pattern []string, parts []string) bool {
+	if len(pattern) == 0 {
+		return len(parts) == 0
+	}
+
+	if pattern[0] == "**" {
+		for i := 0; i <= len(parts); i++ {
+			if matchSegments(pattern[1:], parts[i:]) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	if len(parts) == 0 {
+		return false
+	}
+
+	matched, _ := path.Match(pattern[0], parts[0])
+	return matched && matchSegments(pattern[1:], parts[1:])
+}
+
+func (g glob) match(parts []string) bool {
+	return matchSegments(g.segments, parts)
+}
+
+// Splits a path into segments, dropping the `.` and empty segments that
+// come from paths such as `./a//b`
+func split(p string) []string {
+	parts := []string{}
+
+	for _, part := range strings.Split(p, "/") {
+		if part != "" && part != "." {
+			parts = append(parts, part)
+		}
+	}
+
+	return parts
+}
@@ -0,0 +1,145 @@
+package git
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/sftsrv/tri/tree"
+)
+
+type Statuses struct {
+	root  string
+	files map[string]tree.Status
+}
+
+func run(args ...string) (string, error) {
+	output, err := exec.Command("git", args...).Output()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %w", strings.Join(args, " "), err)
+	}
+
+	return string(output), nil
+}
+
+func toStatus(code byte) tree.Status {
+	switch code {
+	case 'A', 'C':
+		return tree.Added
+	case 'M', 'T', 'U':
+		return tree.Modified
+	case 'D':
+		return tree.Deleted
+	case 'R':
+		return tree.Renamed
+	case '?':
+		return tree.Untracked
+	}
+
+	return tree.Unchanged
+}
+
+// Parses `git diff --name-status -z` where renames and copies are followed
+// by both the old and new path
+func (s *Statuses) parseDiff(output string) {
+	fields := strings.Split(strings.TrimSuffix(output, "\x00"), "\x00")
+
+	for i := 0; i+1 < len(fields); i += 2 {
+		code := fields[i][0]
+		path := fields[i+1]
+
+		if code == 'R' || code == 'C' {
+			i++
+			if i+1 >= len(fields) {
+				break
+			}
+			path = fields[i+1]
+		}
+
+		s.files[path] = toStatus(code)
+	}
+}
+
+// Parses `git status --porcelain -z` where renames and copies are followed
+// by the old path as a separate entry
+func (s *Statuses) parseStatus(output string) {
+	entries := strings.Split(strings.TrimSuffix(output, "\x00"), "\x00")
+
+	for i := 0; i < len(entries); i++ {
+		entry := entries[i]
+		if len(entry) < 4 {
+			continue
+		}
+
+		x, y, path := entry[0], entry[1], entry[3:]
+		if x == 'R' || x == 'C' {
+			i++
+		}
+
+		// staged changes take precedence over unstaged ones when both are present
+		status := toStatus(x)
+		if status == tree.Unchanged {
+			status = toStatus(y)
+		}
+
+		s.files[path] = status
+	}
+}
+
+// Reads the status of the repository containing the current directory. If `ref`
+// is set then files that changed since that ref are included as well
+func Load(ref string) (Statuses, error) {
+	statuses := Statuses{files: map[string]tree.Status{}}
+
+	root, err := run("rev-parse", "--show-toplevel")
+	if err != nil {
+		return statuses, err
+	}
+	statuses.root = strings.TrimSpace(root)
+
+	if ref != "" {
+		diff, err := run("diff", "--name-status", "-z", ref)
+		if err != nil {
+			return statuses, err
+		}
+
+		statuses.parseDiff(diff)
+	}
+
+	status, err := run("status", "--porcelain", "-z", "--untracked-files=all")
+	if err != nil {
+		return statuses, err
+	}
+
+	statuses.parseStatus(status)
+
+	return statuses, nil
+}
+
+// Git reports paths relative to the repository root while input paths are
+// usually relative to the current directory, but tools like `git diff` also
+// output root relative paths so both are checked
+func (s Statuses) Lookup(path string) tree.Status {
+	abs, err := filepath.Abs(path)
+	if err == nil {
+		rel, err := filepath.Rel(s.root, abs)
+		if err == nil {
+			if status, ok := s.files[filepath.ToSlash(rel)]; ok {
+				return status
+			}
+		}
+	}
+
+	return s.files[filepath.ToSlash(filepath.Clean(path))]
+}
+
+func (s Statuses) Decorate(t *tree.Tree) {
+	if len(t.Children) == 0 {
+		t.Status = s.Lookup(t.Path)
+	}
+
+	for _, child := range t.Children {
+		s.Decorate(child)
+	}
+}
@@ -0,0 +1,136 @@
+package input
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/sftsrv/tri/tree"
+)
+
+const (
+	FormatLines = "lines"
+	FormatJSONL = "jsonl"
+)
+
+// Lines are allowed to grow up to this size instead of the default 64KiB
+const maxLineSize = 256 * 1024 * 1024
+
+type Options struct {
+	Format string
+	// Field that holds the path of each object when using `jsonl`
+	PathField string
+	// Split lines on NUL instead of newlines, as output by `find -print0`
+	Read0 bool
+}
+
+func scanNul(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+
+	if i := bytes.IndexByte(data, 0); i >= 0 {
+		return i + 1, data[:i], nil
+	}
+
+	if atEOF {
+		return len(data), data, nil
+	}
+
+	return 0, nil, nil
+}
+
+// Scans lines of up to maxLineSize, such as long minified files in grep output
+func NewScanner(r io.Reader) *bufio.Scanner {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+
+	return scanner
+}
+
+func readLines(r io.Reader, read0 bool) ([]tree.Entry, error) {
+	entries := []tree.Entry{}
+
+	scanner := NewScanner(r)
+
+	if read0 {
+		scanner.Split(scanNul)
+	}
+
+	for scanner.Scan() {
+		// NUL separated paths are taken as-is since spaces may be part of the name
+		line := scanner.Text()
+		if !read0 {
+			line = strings.TrimSpace(line)
+		}
+
+		entries = append(entries, tree.Entry{Key: line, Line: line})
+	}
+
+	return entries, scanner.Err()
+}
+
+// Nested values are kept as compact JSON so that they can still be used
+func fieldValue(value any) string {
+	switch value := value.(type) {
+	case string:
+		return value
+	case nil:
+		return ""
+	}
+
+	bytes, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+
+	return string(bytes)
+}
+
+// Reads a stream of JSON objects, they do not need to be separated by newlines
+func readJSONL(r io.Reader, pathField string) ([]tree.Entry, error) {
+	entries := []tree.Entry{}
+
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber()
+
+	for {
+		object := map[string]any{}
+
+		err := decoder.Decode(&object)
+		if err == io.EOF {
+			return entries, nil
+		}
+
+		if err != nil {
+			return entries, fmt.Errorf("invalid json on object %d: %w", len(entries)+1, err)
+		}
+
+		path, ok := object[pathField].(string)
+		if !ok {
+			return entries, fmt.Errorf("object %d does not have a string %q field", len(entries)+1, pathField)
+		}
+
+		fields := map[string]string{}
+		for name, value := range object {
+			fields[name] = fieldValue(value)
+		}
+
+		entries = append(entries, tree.Entry{Key: path, Line: path, Fields: fields})
+	}
+}
+
+func Read(r io.Reader, opts Options) ([]tree.Entry, error) {
+	switch opts.Format {
+	case FormatLines, "":
+		return readLines(r, opts.Read0)
+
+	case FormatJSONL:
+		return readJSONL(r, opts.PathField)
+	}
+
+	return nil, fmt.Errorf("unknown input format %q, expected %s or %s", opts.Format, FormatLines, FormatJSONL)
+}
@@ -0,0 +1,256 @@
+package keymap
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type KeyMap struct {
+	Search      key.Binding
+	CloseSearch key.Binding
+	Up          key.Binding
+	Down        key.Binding
+	Select      key.Binding
+
+	Expand       key.Binding
+	Collapse     key.Binding
+	ExpandLevel  key.Binding
+	ExpandAll    key.Binding
+	CollapseAll  key.Binding
+	Sort         key.Binding
+	FoldersFirst key.Binding
+	Export       key.Binding
+	Grow         key.Binding
+	Shrink       key.Binding
+	Reload       key.Binding
+	Quit         key.Binding
+}
+
+func bind(desc string, keys ...string) key.Binding {
+	return key.NewBinding(key.WithKeys(keys...), key.WithHelp("", desc))
+}
+
+func Default() KeyMap {
+	return KeyMap{
+		Search:      bind("search", "/"),
+		CloseSearch: bind("close search", "esc"),
+		Up:          bind("navigate", "up", "k"),
+		Down:        bind("navigate", "down", "j"),
+		Select:      bind("select", "enter", " "),
+
+		Expand:       bind("expand", "right", "l"),
+		Collapse:     bind("collapse", "left", "h"),
+		ExpandLevel:  bind("expand level", ">"),
+		ExpandAll:    bind("expand/collapse all", "]"),
+		CollapseAll:  bind("expand/collapse all", "["),
+		Sort:         bind("sort", "s"),
+		FoldersFirst: bind("sort", "S"),
+		Export:       bind("export", "e"),
+		Grow:         bind("resize", "}"),
+		Shrink:       bind("resize", "{"),
+		Reload:       bind("reload", "ctrl+r"),
+		Quit:         bind("quit", "ctrl+c", "q"),
+	}
+}
+
+type named struct {
+	name    string
+	binding *key.Binding
+	// not shown in the help
+	hidden bool
+	// only shown in the help while searching
+	searchOnly bool
+}
+
+// Bindings along with the names used for them in the config file, in the
+// order that they are shown in the help
+func (k *KeyMap) named() []named {
+	return []named{
+		{name: "search", binding: &k.Search},
+		{name: "close-search", binding: &k.CloseSearch, searchOnly: true},
+		{name: "up", binding: &k.Up},
+		{name: "down", binding: &k.Down},
+		{name: "select", binding: &k.Select, hidden: true},
+		{name: "expand", binding: &k.Expand},
+		{name: "collapse", binding: &k.Collapse},
+		{name: "expand-level", binding: &k.ExpandLevel},
+		{name: "expand-all", binding: &k.ExpandAll},
+		{name: "collapse-all", binding: &k.CollapseAll},
+		{name: "sort", binding: &k.Sort},
+		{name: "folders-first", binding: &k.FoldersFirst},
+		{name: "export", binding: &k.Export},
+		{name: "grow", binding: &k.Grow},
+		{name: "shrink", binding: &k.Shrink},
+		{name: "reload", binding: &k.Reload},
+		{name: "quit", binding: &k.Quit},
+	}
+}
+
+// Keys can be written like `ctrl-e` as well as bubbletea's `ctrl+e`
+var keyReplacer = strings.NewReplacer("ctrl-", "ctrl+", "alt-", "alt+", "shift-", "shift+")
+
+func NormalizeKey(key string) string {
+	return keyReplacer.Replace(key)
+}
+
+// The default keymap with the keys of some of its bindings replaced, by the
+// names used in the config file
+func New(overrides map[string][]string) (KeyMap, error) {
+	k := Default()
+
+	bindings := k.named()
+	for name, keys := range overrides {
+		i := slices.IndexFunc(bindings, func(n named) bool {
+			return n.name == name
+		})
+
+		if i < 0 {
+			return k, fmt.Errorf("unknown key binding %q, expected one of: %s", name, strings.Join(k.Names(), ", "))
+		}
+
+		normalized := []string{}
+		for _, key := range keys {
+			normalized = append(normalized, NormalizeKey(key))
+		}
+
+		bindings[i].binding.SetKeys(normalized...)
+	}
+
+	return k, k.Validate()
+}
+
+func (k KeyMap) Names() []string {
+	names := []string{}
+	for _, n := range k.named() {
+		names = append(names, n.name)
+	}
+
+	return names
+}
+
+// The keys of each binding by name
+func (k KeyMap) Bindings() map[string]any {
+	bindings := map[string]any{}
+	for _, n := range k.named() {
+		bindings[n.name] = n.binding.Keys()
+	}
+
+	return bindings
+}
+
+// Checks that no key is used for more than one binding
+func (k KeyMap) Validate() error {
+	used := map[string]string{}
+
+	for _, n := range k.named() {
+		for _, key := range n.binding.Keys() {
+			other, ok := used[key]
+			if ok && other != n.name {
+				return fmt.Errorf("key %q is bound to both %s and %s", key, other, n.name)
+			}
+
+			used[key] = n.name
+		}
+	}
+
+	return nil
+}
+
+// Removes the keys from every binding, so that they can be used for
+// something else
+func (k *KeyMap) Unbind(keys ...string) {
+	for _, n := range k.named() {
+		kept := slices.DeleteFunc(slices.Clone(n.binding.Keys()), func(key string) bool {
+			return slices.Contains(keys, key)
+		})
+
+		n.binding.SetKeys(kept...)
+	}
+}
+
+// Keys that type a single character, these go to the search while searching
+func isPrintable(key string) bool {
+	return utf8.RuneCountInString(key) == 1
+}
+
+// Whether the key triggers the binding. While searching, only keys that
+// don't type a character do
+func Matches(msg tea.KeyMsg, searching bool, binding key.Binding) bool {
+	if searching && isPrintable(msg.String()) {
+		return false
+	}
+
+	return key.Matches(msg, binding)
+}
+
+var arrows = map[string]string{
+	"up":    "↑",
+	"down":  "↓",
+	"left":  "←",
+	"right": "→",
+}
+
+// Shows arrows as their symbols next to each other, followed by the rest
+// of the keys, e.g. `↑↓/k/j`
+func formatKeys(keys []string) string {
+	symbols := ""
+	others := []string{}
+
+	for _, key := range keys {
+		if arrow, ok := arrows[key]; ok {
+			symbols += arrow
+		} else if key == " " {
+			others = append(others, "space")
+		} else {
+			others = append(others, key)
+		}
+	}
+
+	if symbols != "" {
+		others = append([]string{symbols}, others...)
+	}
+
+	return strings.Join(others, "/")
+}
+
+// Help for the enabled bindings that can be used in the current mode, with
+// consecutive bindings that share a description shown together
+func (k KeyMap) Help(searching bool) []key.Help {
+	help := []key.Help{}
+	keys := [][]string{}
+
+	for _, n := range k.named() {
+		if n.hidden || !n.binding.Enabled() || (n.searchOnly && !searching) {
+			continue
+		}
+
+		usable := slices.DeleteFunc(slices.Clone(n.binding.Keys()), func(key string) bool {
+			return searching && isPrintable(key)
+		})
+
+		if len(usable) == 0 {
+			continue
+		}
+
+		desc := n.binding.Help().Desc
+		last := len(help) - 1
+		if last >= 0 && help[last].Desc == desc {
+			keys[last] = append(keys[last], usable...)
+			continue
+		}
+
+		help = append(help, key.Help{Desc: desc})
+		keys = append(keys, usable)
+	}
+
+	for i := range help {
+		help[i].Key = formatKeys(keys[i])
+	}
+
+	return help
+}
@@ -1,14 +1,31 @@
 package main
 
 import (
-	"bufio"
+	"bytes"
+	"context"
 	"flag"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 
+	"github.com/BurntSushi/toml"
+	"github.com/sftsrv/tri/command"
+	"github.com/sftsrv/tri/config"
+	"github.com/sftsrv/tri/document"
+	"github.com/sftsrv/tri/export"
+	"github.com/sftsrv/tri/filter"
+	"github.com/sftsrv/tri/git"
+	"github.com/sftsrv/tri/input"
+	"github.com/sftsrv/tri/keymap"
+	previewpkg "github.com/sftsrv/tri/preview"
+	"github.com/sftsrv/tri/theme"
 	"github.com/sftsrv/tri/tree"
 	"github.com/sftsrv/tri/ui"
+	"github.com/sftsrv/tri/walk"
+	"github.com/sftsrv/tri/watch"
 )
 
 var usage = `tri
@@ -18,7 +35,32 @@ An interactive tree-based search tool with file preview. Give it some path-looki
 ## Usage
 
 '''
-# files in a directory
+# files in the current directory, or in the given ones
+tri
+tri --hidden --max-depth 3 src docs
+
+# browse everything, only reading folders as they are opened or searched into
+tri --lazy /
+
+# keep the tree up to date while a build is running
+tri --watch
+
+# read paths from a command, press ctrl+r to run it again
+tri --source "git diff --name-only main"
+
+# $query is replaced with the current search when reloading
+tri --source 'git ls-files | grep -- $query'
+
+# live grep, the search is passed to rg and the files it finds replace the tree as you type
+tri --source 'rg -l -- $query' --live
+
+# open the hovered file in an editor and come back to the tree, or copy its path
+git ls-files | tri --bind "ctrl-e:execute(nvim $)" --bind "ctrl-y:execute-silent(wl-copy $)"
+
+# reload the tree from another command
+tri --source "git diff --name-only" --bind "ctrl-u:reload(git ls-files --others --exclude-standard)"
+
+# files from another command
 find ./ | tri
 
 # use alternate preview
@@ -26,6 +68,37 @@ find ./ | tri --preview glow
 
 # files in a pr
 git diff --name-only | tri
+
+# files in a pr along with their git status
+git diff --name-only main | tri --git-ref main
+
+# print the tree like the tree command, other formats are ascii, markdown and json
+git ls-files | tri --print --format tree
+
+# diagrams for docs, in app the tree can be written to a file with e
+git ls-files | tri --print --format mermaid --export-depth 2 --export-max-children 5
+git ls-files | tri --export-file files.dot
+
+# share the files in a pr as a single html page, including their diffs
+git diff --name-only main | tri --export-html pr.html --export-html-previews --preview "git diff main --"
+
+# absolute or messy paths
+find "$PWD" | tri --normalize --strip-prefix
+
+# file names with spaces or newlines
+find ./ -print0 | tri -0 --print0 | xargs -0 ls -l
+
+# skip dependencies and anything git ignores
+find ./ | tri --exclude node_modules --exclude "vendor/**" --gitignore
+
+# only show the first two levels, press > to expand one more level
+find ./ | tri --depth 2
+
+# show file counts and sizes next to each item
+find ./ | tri --metrics count,size
+
+# folders first, then sorted with numbers in order (press s/S to change while running)
+find ./ | tri --sort folders,natural
 '''
 
 ### Using Patterns
@@ -42,6 +115,111 @@ git log --pretty=format:"%h %f"
 # using a more complex regexp and command
 git log --pretty=format:"%h %f"
 | tri --preview "echo title: $title hash: $hash" --pattern "(?<hash>\w+) (?<title>.*)"
+
+# keep the commits in chronological order instead of sorting them by hash
+git log --pretty=format:"%h %f"
+| tri --preview "git show $1" --pattern "^(\w+)" --keep-order
+
+# group commits by author and date, the whole line is still used for previews and output
+git log --pretty=format:"%h %an %as"
+| tri --preview "git show $hash" --pattern "(?<hash>\w+) (?<author>.*) (?<date>\S+)" --key '$author/$date/$hash'
+'''
+
+### Using Separators
+
+Paths are split on '/' by default, other separators can be used for things that aren't file paths
+
+'''
+# java packages
+cat classes.txt | tri --separator .
+
+# redis keys, split on ':' or '.'
+redis-cli --scan | tri --separator-regexp "[:.]"
+
+# s3 urls, treating '//' as a single separator
+aws s3 ls --recursive s3://bucket | tri --separator-regexp "/+"
+
+# windows paths
+dir /s /b | tri --windows
+'''
+
+### Using JSON
+
+Objects can be read with '--input-format jsonl', their fields can be referenced like named capture groups
+
+'''
+# pods grouped by namespace, showing their status
+kubectl get pods -A -o json
+| jq -c '.items[] | {namespace: .metadata.namespace, name: .metadata.name, phase: .status.phase}'
+| tri --input-format jsonl --path-field name --key '$namespace/$name' --columns phase
+  --preview "kubectl describe pod $name -n $namespace"
+'''
+
+### Browsing Documents
+
+JSON, YAML and TOML documents can be browsed by their keys, the selected key is output as a jq path
+
+'''
+# find a value in a config file, then get it with jq
+jq "$(tri --input-format json < package.json)" package.json
+
+kubectl get deploy my-app -o yaml | tri --input-format yaml
+'''
+
+### Configuration
+
+Defaults for flags, named profiles of flags and keys can be set in '$XDG_CONFIG_HOME/tri/config' (or '~/.config/tri/config'),
+which is TOML. Flags given on the command line take precedence over a profile, which takes precedence over the defaults.
+Keys that are bound to more than one action are reported when tri starts, and keys used by '--bind' take the place of built in ones
+
+'''
+[defaults]
+sort = "folders,natural"
+exclude = ["node_modules", "vendor"]
+
+# used with tri -p gitlog
+[profiles.gitlog]
+pattern = '^(\w+)'
+preview = "git show $1"
+keep-order = true
+
+[keys]
+up = ["up", "ctrl+p"]
+down = ["down", "ctrl+n"]
+expand = "right"
+collapse = "left"
+'''
+
+The names of the bindings are search, close-search, up, down, select, expand, collapse, expand-level, expand-all,
+collapse-all, sort, folders-first, export, grow, shrink, reload and quit
+
+'''
+# show the flags and keys in effect
+tri -p gitlog --print-config
+'''
+
+### Themes
+
+Colors suit the terminal's background by default, '--theme' picks one of the dark, light, high-contrast or mono presets instead.
+Setting 'NO_COLOR' turns colors off whatever the theme
+
+'''
+tri --theme light
+NO_COLOR=1 tri
+'''
+
+A theme can also be a TOML file of colors, which can be ANSI numbers or hex values. Colors left out are taken from the preset that suits the terminal
+
+'''
+# used with tri --theme theme.toml, or with theme = "/path/to/theme.toml" in the config defaults
+primary = "#c678dd"
+secondary = "#61afef"
+faded = "244"
+text = "15"
+warn = "#e5c07b"
+error = "#e06c75"
+success = "#98c379"
+info = "#56b6c2"
 '''
 
 ### Using Regexps
@@ -65,9 +243,52 @@ References in pattern are indicated with a '$' in the pattern
 func main() {
 	help := flag.Bool("help", false, "show help menu")
 	print := flag.Bool("print", false, "print tree (non interactive)")
+	format := flag.String("format", export.FormatIcons, "format used by --print: "+strings.Join(export.Formats, ", "))
+	exportFile := flag.String("export-file", "", "file to write the tree to when pressing e, the format is based on the extension unless --export-format is set")
+	exportFormat := flag.String("export-format", "", "format used for --export-file")
+	exportHTML := flag.String("export-html", "", "write a self-contained html page of the tree to this file and exit")
+	exportHTMLPreviews := flag.Bool("export-html-previews", false, "embed the preview of each file in the --export-html page")
+	exportDepth := flag.Int("export-depth", 0, "maximum depth of dot, mermaid and mindmap exports")
+	exportMaxChildren := flag.Int("export-max-children", 0, "maximum children of a folder in dot, mermaid and mindmap exports, the rest are shown as a single node")
 	flat := flag.Bool("flat", false, "flatten direct paths")
+	depth := flag.Int("depth", 0, "depth that folders are initially expanded to, 0 expands everything")
 	preview := flag.String("preview", "", "command to use for file preview")
 	pattern := flag.String("pattern", "", "pattern to use when parsing path")
+	key := flag.String("key", "", "template using references from --pattern or input fields to build the tree path from, e.g. $author/$hash")
+	metrics := flag.String("metrics", "", "comma separated metrics to show next to items: count, size")
+	sort := flag.String("sort", "name", "sort order of items: name, natural, nocase, size, mtime, input - optionally prefixed with folders, e.g. folders,natural")
+	keepOrder := flag.Bool("keep-order", false, "keep items in the order they were first seen in the input, same as --sort input")
+	separator := flag.String("separator", tree.SEP, "separator used to split paths into a tree")
+	separatorRegexp := flag.String("separator-regexp", "", "regexp used to split paths into a tree, overrides --separator")
+	normalize := flag.Bool("normalize", false, "clean up paths like ./a//b/ so they match a/b, . and ./ are the top of the tree. The original paths are still output")
+	stripPrefix := flag.Bool("strip-prefix", false, "start the tree at the deepest folder shared by all paths")
+	configPath := flag.String("config", config.Path(), "config file to read key bindings, defaults and profiles from")
+	profile := flag.String("profile", "", "profile from the config file to use, flags given on the command line take precedence over it")
+	flag.StringVar(profile, "p", "", "shorthand for --profile")
+	printConfig := flag.Bool("print-config", false, "print the flags and keys in effect after reading the config file and exit")
+	var bindings bindFlag
+	flag.Var(&bindings, "bind", "run a command with a key, e.g. ctrl-e:execute(nvim $). Actions are "+strings.Join(ui.Actions, ", ")+", reload runs --source or the given command (repeatable)")
+	var include, exclude listFlag
+	flag.Var(&include, "include", "only include paths matching this glob, ** matches any number of folders (repeatable)")
+	flag.Var(&exclude, "exclude", "exclude paths matching this glob along with everything under them (repeatable)")
+	gitignore := flag.Bool("gitignore", false, "exclude paths ignored by .gitignore files found along the input paths")
+	windows := flag.Bool("windows", false, "split windows paths on either slash, keeping drive letters and UNC names as roots. Can't be used with --separator or --separator-regexp")
+	inputFormat := flag.String("input-format", input.FormatLines, "format of stdin: lines, jsonl, or a json, yaml or toml document to browse")
+	pathField := flag.String("path-field", "path", "field holding the path of each object when using --input-format jsonl")
+	columns := flag.String("columns", "", "comma separated fields to show as columns next to items when using --input-format jsonl")
+	read0 := flag.Bool("read0", false, "read input delimited by NUL instead of newlines, such as from find -print0")
+	flag.BoolVar(read0, "0", false, "shorthand for --read0")
+	print0 := flag.Bool("print0", false, "terminate the selected path with NUL instead of a newline")
+	gitStatus := flag.Bool("git", false, "show git status of files from the local repository")
+	gitRef := flag.String("git-ref", "", "also show files changed since this git ref (implies --git)")
+	hidden := flag.Bool("hidden", false, "include hidden files when walking folders")
+	follow := flag.Bool("follow", false, "follow symlinks to folders when walking folders")
+	maxDepth := flag.Int("max-depth", 0, "how many levels below each folder to walk, 0 walks everything")
+	watchFlag := flag.Bool("watch", false, "update the tree as files are created, changed or deleted in the walked folders")
+	source := flag.String("source", "", "command to read the input from instead of stdin, re-run with ctrl+r to reload the tree. $query is replaced with the current search")
+	live := flag.Bool("live", false, "run --source again as the search changes, replacing the tree with its output")
+	lazy := flag.Bool("lazy", false, "only read folders when they are expanded or searched into, for browsing huge folders such as /")
+	themeName := flag.String("theme", theme.Auto, "colors to use: "+strings.Join(theme.Names, ", ")+" or a TOML file of colors, auto picks dark or light to suit the terminal. NO_COLOR turns colors off")
 
 	flag.Parse()
 
@@ -77,28 +298,573 @@ func main() {
 		return
 	}
 
-	paths := []string{}
+	file, err := config.Load(*configPath)
+	if err != nil {
+		exitWithError(err)
+	}
+
+	err = applyConfig(file, *profile)
+	if err != nil {
+		exitWithError(err)
+	}
+
+	overrides, err := file.KeyOverrides()
+	if err != nil {
+		exitWithError(err)
+	}
+
+	keys, err := keymap.New(overrides)
+	if err != nil {
+		exitWithError(err)
+	}
+
+	// bindings take the place of any built in actions using the same keys
+	for _, binding := range bindings {
+		keys.Unbind(binding.Key)
+	}
+
+	if *printConfig {
+		err := writeConfig(os.Stdout, keys)
+		if err != nil {
+			exitWithError(err)
+		}
+
+		return
+	}
+
+	opts := tree.Options{}
+
+	opts.Theme, err = theme.Load(*themeName)
+	if err != nil {
+		exitWithError(err)
+	}
+
+	opts.Metrics, err = tree.ParseMetrics(*metrics)
+	if err != nil {
+		exitWithError(err)
+	}
+
+	opts.Sort, err = tree.ParseSort(*sort)
+	if err != nil {
+		exitWithError(err)
+	}
 
-	scanner := bufio.NewScanner(os.Stdin)
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		paths = append(paths, line)
+	if *keepOrder {
+		opts.Sort.Mode = tree.SortInput
 	}
 
-	if len(paths) == 0 {
-		panic("Expected to be called with a list of paths from stdin")
+	opts.Columns = splitList(*columns)
+
+	if *windows && (*separatorRegexp != "" || *separator != tree.SEP) {
+		exitWithError(fmt.Errorf("--windows can't be used with --separator or --separator-regexp since it splits on both slashes"))
 	}
 
-	t := tree.PathsToTree(paths)
+	sep := tree.NewSeparator(*separator)
+	if *windows {
+		sep = tree.WindowsSeparator
+	}
+
+	if *separatorRegexp != "" {
+		sep, err = tree.NewSeparatorRegexp(*separatorRegexp)
+		if err != nil {
+			exitWithError(err)
+		}
+	}
+
+	build := tree.BuildOptions{
+		Separator:   sep,
+		Normalize:   *normalize,
+		StripPrefix: *stripPrefix,
+	}
+
+	var keepFolder func(path string) bool
+	if len(include) > 0 || len(exclude) > 0 || *gitignore {
+		f, err := filter.New(include, exclude, *gitignore)
+		if err != nil {
+			exitWithError(err)
+		}
+
+		build.Keep = f.Keep
+		keepFolder = f.KeepFolder
+	}
+
+	if *key != "" && slices.Contains(document.Formats, *inputFormat) {
+		exitWithError(fmt.Errorf("--key can't be used with --input-format %s, documents are keyed by their paths", *inputFormat))
+	}
+
+	if *key != "" && *pattern == "" && *inputFormat != input.FormatJSONL {
+		exitWithError(fmt.Errorf("--key requires a --pattern or fields to reference"))
+	}
+
+	inputOpts := input.Options{
+		Format:    *inputFormat,
+		PathField: *pathField,
+		Read0:     *read0,
+	}
+
+	// runs a source command with the current search, for the initial input
+	// and then again whenever the tree is reloaded
+	reload := func(source string, query string) ([]tree.Entry, error) {
+		output, err := command.Source(context.Background(), source, query).Output()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", source, err)
+		}
+
+		entries, err := input.Read(bytes.NewReader(output), inputOpts)
+		if err != nil {
+			return nil, err
+		}
+
+		if *key != "" {
+			err = applyKey(entries, *key, *pattern)
+		}
+
+		return entries, err
+	}
+
+	if *source != "" && slices.Contains(document.Formats, *inputFormat) {
+		exitWithError(fmt.Errorf("--source can't be used with --input-format %s", *inputFormat))
+	}
+
+	// reloaded paths are added to the tree as they are, which no longer has
+	// the prefix that was stripped
+	reloads := *source != "" || slices.ContainsFunc(bindings, func(b ui.Binding) bool {
+		return b.Action == ui.ActionReload
+	})
+
+	if *stripPrefix && reloads {
+		exitWithError(fmt.Errorf("--strip-prefix can't be used with --source or reload bindings"))
+	}
+
+	liveSource := ""
+	if *live {
+		if *source == "" {
+			exitWithError(fmt.Errorf("--live requires a --source command"))
+		}
+
+		if *print || *exportHTML != "" {
+			exitWithError(fmt.Errorf("--live can only be used interactively"))
+		}
+
+		if *key != "" || *inputFormat != input.FormatLines {
+			exitWithError(fmt.Errorf("--live only reads paths, one per line"))
+		}
+
+		liveSource = *source
+	}
+
+	// folders are walked when given or when nothing is piped in
+	roots := flag.Args()
+	walking := *source == "" && (len(roots) > 0 || !isPiped(os.Stdin))
+	if walking && len(roots) == 0 {
+		roots = []string{"."}
+	}
+
+	walkOpts := walk.Options{
+		Hidden:     *hidden,
+		Follow:     *follow,
+		MaxDepth:   *maxDepth,
+		Keep:       build.Keep,
+		KeepFolder: keepFolder,
+	}
+
+	// the interactive tree is filled in while walking unless something needs
+	// every path up front
+	streaming := walking && !*print && *exportHTML == "" && *key == "" &&
+		!*stripPrefix && !*gitStatus && *gitRef == ""
+
+	// folders are read by the ui as they are expanded
+	lazyLoading := streaming && *lazy
+	streaming = streaming && !lazyLoading
+
+	var entries []tree.Entry
+	var previewSource func(string) string
+
+	switch {
+	case walking && *inputFormat != input.FormatLines:
+		exitWithError(fmt.Errorf("--input-format %s can only be used with stdin", *inputFormat))
+
+	case streaming, lazyLoading:
+		// paths are added by the ui as they are found
+
+	case *live:
+		// the ui runs the source as the search changes
+
+	case *source != "":
+		entries, err = reload(*source, "")
+		if err != nil {
+			exitWithError(err)
+		}
+
+	case walking:
+		paths := make(chan string)
+		go walk.Walk(roots, walkOpts, paths)
+
+		for path := range paths {
+			entries = append(entries, tree.Entry{Key: path, Line: path})
+		}
+
+	case slices.Contains(document.Formats, *inputFormat):
+		doc, err := document.Read(os.Stdin, *inputFormat)
+		if err != nil {
+			exitWithError(err)
+		}
+
+		entries = doc.Entries()
+		previewSource = doc.Preview
+
+		// keys are shown in document order unless asked otherwise
+		if !isFlagSet("sort") {
+			opts.Sort.Mode = tree.SortInput
+		}
+
+	default:
+		entries, err = input.Read(os.Stdin, inputOpts)
+		if err != nil {
+			exitWithError(err)
+		}
+	}
+
+	// an empty source is fine since it can be reloaded
+	if len(entries) == 0 && !walking && *source == "" {
+		exitWithError(fmt.Errorf("no paths were read from stdin"))
+	}
+
+	if *key != "" && *source == "" {
+		err = applyKey(entries, *key, *pattern)
+		if err != nil {
+			exitWithError(err)
+		}
+	}
+
+	if *key != "" {
+		warnDuplicateKeys(entries)
+	}
+
+	t := tree.EntriesToTree(entries, build)
+
+	var decorate func() func(t *tree.Tree)
+	if *gitStatus || *gitRef != "" {
+		statuses, err := git.Load(*gitRef)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Could not read git status: %v\n", err)
+		} else {
+			statuses.Decorate(t)
+
+			// the status is read again for reloads since the files have
+			// likely changed, the old one is kept if that fails
+			decorate = func() func(t *tree.Tree) {
+				statuses, err := git.Load(*gitRef)
+				if err != nil {
+					return nil
+				}
+
+				return statuses.Decorate
+			}
+		}
+	}
+
+	if *exportHTML != "" {
+		var previewer export.Previewer
+		if *exportHTMLPreviews {
+			previewer = func(line string, fields map[string]string) string {
+				if previewSource != nil {
+					return previewSource(line)
+				}
+
+				return previewpkg.Capture(*preview, *pattern, line, fields, 120, opts.Theme)
+			}
+		}
+
+		if *depth > 0 {
+			t.ExpandDepth(*depth)
+		}
+
+		if *flat {
+			t.Flatten()
+		}
+
+		page := export.HTML(t, opts, filepath.Base(*exportHTML), previewer)
+		err := os.WriteFile(*exportHTML, []byte(page), 0644)
+		if err != nil {
+			exitWithError(err)
+		}
+
+		return
+	}
 
 	if *print {
 		t.ExpandAll()
+		if *depth > 0 {
+			t.ExpandDepth(*depth)
+		}
+
 		if *flat {
 			t.Flatten()
 		}
-		fmt.Println(tree.Render(t))
+		output, err := export.Render(t, opts, export.Config{
+			Format:      *format,
+			Depth:       *exportDepth,
+			MaxChildren: *exportMaxChildren,
+		})
+		if err != nil {
+			exitWithError(err)
+		}
+
+		fmt.Println(output)
 		return
 	}
 
-	ui.Run(t, *preview, *pattern, *flat)
+	if *exportFormat == "" {
+		*exportFormat = export.FormatForFile(*exportFile)
+	}
+
+	var stream chan string
+	if streaming {
+		stream = make(chan string)
+		go walk.Walk(roots, walkOpts, stream)
+	}
+
+	var events chan watch.Event
+	var watcher *watch.Watcher
+	if *watchFlag {
+		if !streaming && !lazyLoading {
+			exitWithError(fmt.Errorf("--watch can only be used when walking folders interactively"))
+		}
+
+		events = make(chan watch.Event)
+		watcher, err = watch.New(walkOpts, !lazyLoading, events)
+		if err != nil {
+			exitWithError(err)
+		}
+
+		// lazy folders are watched by the ui as they are read
+		if streaming {
+			go watcher.AddTrees(roots)
+		}
+	}
+
+	ui.Run(t, ui.Config{
+		Stream:        stream,
+		Build:         build,
+		Lazy:          lazyLoading,
+		Roots:         roots,
+		Walk:          walkOpts,
+		Watch:         events,
+		Watcher:       watcher,
+		Reload:        reload,
+		Source:        *source,
+		Decorate:      decorate,
+		Bindings:      bindings,
+		KeyMap:        &keys,
+		Live:          liveSource,
+		Preview:       *preview,
+		Pattern:       *pattern,
+		Flat:          *flat,
+		Depth:         *depth,
+		Options:       opts,
+		PreviewSource: previewSource,
+		Print0:        *print0,
+		ExportFile:    *exportFile,
+		Export: export.Config{
+			Format:      *exportFormat,
+			Depth:       *exportDepth,
+			MaxChildren: *exportMaxChildren,
+		},
+	})
+}
+
+// Replaces the key of each entry using its fields and the captures from the
+// pattern. Lines that don't match the pattern are kept as-is
+func applyKey(entries []tree.Entry, key string, pattern string) error {
+	for i, entry := range entries {
+		expanded := command.ExpandFields(key, entry.Fields)
+
+		if pattern != "" {
+			var matched bool
+			var err error
+
+			expanded, matched, err = command.Expand(expanded, pattern, entry.Line)
+			if err != nil {
+				return err
+			}
+
+			if !matched {
+				continue
+			}
+		}
+
+		entries[i].Key = expanded
+	}
+
+	return nil
+}
+
+// Only the first line for each key is kept in the tree, so lines that share
+// a key with an earlier one are reported since they can't be selected
+func warnDuplicateKeys(entries []tree.Entry) {
+	seen := map[string]bool{}
+	duplicates := []string{}
+
+	for _, entry := range entries {
+		if seen[entry.Key] {
+			duplicates = append(duplicates, entry.Line)
+		}
+
+		seen[entry.Key] = true
+	}
+
+	if len(duplicates) > 0 {
+		fmt.Fprintf(os.Stderr, "Left out %d line(s) with the same --key as an earlier line, such as: %s\n", len(duplicates), duplicates[0])
+	}
+}
+
+// A flag that can be repeated or given a comma separated list
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *listFlag) Get() any {
+	return []string(*l)
+}
+
+func (l *listFlag) Set(value string) error {
+	*l = append(*l, splitList(value)...)
+	return nil
+}
+
+// Flags that only affect how the config is read, or are shorthands for others
+var configFlags = []string{"help", "config", "profile", "p", "print-config", "0"}
+
+// Sets the flags from the config file that weren't given on the command line
+func applyConfig(file config.File, profile string) error {
+	values, err := file.Flags(profile)
+	if err != nil {
+		return err
+	}
+
+	given := map[string]bool{}
+	flag.Visit(func(f *flag.Flag) {
+		given[f.Name] = true
+	})
+
+	for name, values := range values {
+		if flag.Lookup(name) == nil || slices.Contains(configFlags, name) {
+			return fmt.Errorf("unknown flag %q in config", name)
+		}
+
+		if given[name] {
+			continue
+		}
+
+		for _, value := range values {
+			err := flag.Set(name, value)
+			if err != nil {
+				return fmt.Errorf("invalid value for %s in config: %w", name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Writes the flags that differ from their defaults and the keys for each
+// binding in the same format as the config file
+func writeConfig(w io.Writer, keys keymap.KeyMap) error {
+	defaults := map[string]any{}
+	flag.VisitAll(func(f *flag.Flag) {
+		if f.Value.String() == f.DefValue || slices.Contains(configFlags, f.Name) {
+			return
+		}
+
+		getter, ok := f.Value.(flag.Getter)
+		if ok {
+			defaults[f.Name] = getter.Get()
+		} else {
+			defaults[f.Name] = f.Value.String()
+		}
+	})
+
+	return toml.NewEncoder(w).Encode(config.File{
+		Defaults: defaults,
+		Keys:     keys.Bindings(),
+	})
+}
+
+// Key bindings, which can't be comma separated since commands may use commas
+type bindFlag []ui.Binding
+
+func (b *bindFlag) String() string {
+	keys := []string{}
+	for _, binding := range *b {
+		keys = append(keys, binding.Key)
+	}
+
+	return strings.Join(keys, ",")
+}
+
+func (b *bindFlag) Get() any {
+	bindings := []string{}
+	for _, binding := range *b {
+		bindings = append(bindings, binding.String())
+	}
+
+	return bindings
+}
+
+func (b *bindFlag) Set(value string) error {
+	binding, err := ui.ParseBinding(value)
+	if err != nil {
+		return err
+	}
+
+	for _, other := range *b {
+		if other.Key == binding.Key {
+			return fmt.Errorf("key %q is bound more than once", binding.Key)
+		}
+	}
+
+	*b = append(*b, binding)
+	return nil
+}
+
+func isFlagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+
+	return set
+}
+
+func splitList(str string) []string {
+	items := []string{}
+
+	for _, item := range strings.Split(str, ",") {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
+
+func exitWithError(err error) {
+	fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	os.Exit(1)
+}
+
+// Whether something is being piped in rather than it being a terminal
+func isPiped(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+
+	return info.Mode()&os.ModeCharDevice == 0
 }
@@ -1,10 +1,21 @@
 package picker
 
+import (
+	"strings"
+
+	lg "github.com/charmbracelet/lipgloss"
+)
+
 type Item interface {
 	Render() string
 	Search() string
 }
 
+// Items can optionally provide content to be shown right-aligned in the picker
+type Aside interface {
+	Aside() string
+}
+
 type ItemSource[I Item] struct {
 	items []I
 }
@@ -17,11 +28,30 @@ func (s ItemSource[I]) String(i int) string {
 	return s.items[i].Search()
 }
 
-func getTitles[I Item](items []I) []string {
+// Pads the title so that the aside ends at `width`, truncating the title if needed
+func alignAside(title string, aside string, width int) string {
+	asideWidth := lg.Width(aside)
+	titleWidth := width - asideWidth - 1
+	if aside == "" || titleWidth < 1 {
+		return title
+	}
+
+	title = truncate(title, titleWidth)
+	gap := width - lg.Width(title) - asideWidth
+
+	return title + strings.Repeat(" ", gap) + aside
+}
+
+func getTitles[I Item](items []I, width int) []string {
 	strs := []string{}
 
 	for _, i := range items {
-		strs = append(strs, i.Render())
+		title := i.Render()
+		if aside, ok := any(i).(Aside); ok {
+			title = alignAside(title, aside.Aside(), width)
+		}
+
+		strs = append(strs, title)
 	}
 
 	return strs
@@ -6,11 +6,12 @@ import (
 
 	tea "github.com/charmbracelet/bubbletea"
 	lg "github.com/charmbracelet/lipgloss"
+	"github.com/sftsrv/tri/keymap"
 	"github.com/sftsrv/tri/theme"
 )
 
 type Model[I Item] struct {
-	accent    lg.Color
+	theme     theme.Theme
 	title     string
 	search    string
 	searching bool
@@ -19,12 +20,20 @@ type Model[I Item] struct {
 	items     []I
 	filtered  []I
 	width     int
+	// whether the search filters the items, otherwise it is only used as a
+	// query by whoever owns the picker
+	filter bool
+	keys   keymap.KeyMap
 }
 
+// Uses the mono theme until one is given with Theme, since loading the
+// default one asks the terminal for its background
 func New[I Item]() Model[I] {
 	return Model[I]{
-		accent: theme.ColorPrimary,
+		theme:  theme.New(theme.Presets[theme.Mono]),
 		count:  5,
+		filter: true,
+		keys:   keymap.Default(),
 	}
 }
 
@@ -59,6 +68,10 @@ func (m Model[I]) Search(search string) Model[I] {
 	return m.applyFilter()
 }
 
+func (m Model[I]) GetSearch() string {
+	return m.search
+}
+
 func (m Model[I]) Searching(searching bool) Model[I] {
 	m.searching = searching
 	return m.applyFilter()
@@ -68,8 +81,18 @@ func (m Model[I]) IsSearching() bool {
 	return m.searching
 }
 
-func (m Model[I]) Accent(accent lg.Color) Model[I] {
-	m.accent = accent
+func (m Model[I]) KeyMap(keys keymap.KeyMap) Model[I] {
+	m.keys = keys
+	return m
+}
+
+func (m Model[I]) Filter(filter bool) Model[I] {
+	m.filter = filter
+	return m.applyFilter()
+}
+
+func (m Model[I]) Theme(t theme.Theme) Model[I] {
+	m.theme = t
 	return m
 }
 
@@ -89,7 +112,7 @@ func truncate(str string, width int) string {
 		Render(str)
 }
 
-func indicator(accent lg.Color, selected bool, title string) string {
+func indicator(style lg.Style, selected bool, title string) string {
 	if !selected {
 		return lg.NewStyle().
 			PaddingRight(1).
@@ -98,12 +121,10 @@ func indicator(accent lg.Color, selected bool, title string) string {
 
 	line := lg.JoinHorizontal(
 		lg.Top,
-		lg.NewStyle().
+		style.
 			PaddingRight(0).
-			Foreground(accent).
 			Render("→"),
-		lg.NewStyle().
-			Foreground(accent).
+		style.
 			Bold(true).
 			Render(title),
 	)
@@ -120,19 +141,17 @@ func (m Model[I]) View() string {
 	}
 
 	header := lg.JoinVertical(lg.Left,
-		theme.
+		m.theme.
 			Heading.
 			Width(m.width).
-			Background(m.accent).
 			Render(m.title+" "+count),
-		theme.Faded.MarginLeft(1).Render(fallback),
+		m.theme.Faded.MarginLeft(1).Render(fallback),
 	)
 
 	if m.searching {
 		header = lg.JoinVertical(
 			lg.Left,
-			theme.Heading.Width(m.width).
-				Background(m.accent).
+			m.theme.Heading.Width(m.width).
 				Render("Search "+count),
 			m.search+"_",
 		)
@@ -142,11 +161,11 @@ func (m Model[I]) View() string {
 	content := []string{}
 
 	for i, item := range items {
-		content = append(content, truncate(indicator(m.accent, i == cursor, item), m.width-1))
+		content = append(content, truncate(indicator(m.theme.Primary, i == cursor, item), m.width-1))
 	}
 
 	if len(items) < m.count {
-		content = append(content, theme.Faded.Render("no more items"))
+		content = append(content, m.theme.Faded.Render("no more items"))
 	}
 
 	return lg.NewStyle().
@@ -157,12 +176,17 @@ func (m Model[I]) View() string {
 				header,
 				lg.NewStyle().
 					BorderLeft(true).
-					BorderForeground(m.accent).
+					BorderForeground(m.theme.Palette.Primary).
 					BorderStyle(lg.NormalBorder()).
 					Render(lg.JoinVertical(lg.Top, content...)),
 			))
 }
 
+// Space left for an item's title after the border and the cursor indicator
+func (m Model[I]) titleWidth() int {
+	return m.width - 2
+}
+
 // Gets the cursor position in a relative window with one item padding if possible.
 // Prefers to keep cursor at the top
 func (m Model[I]) cursorWindow() (int, []string) {
@@ -170,25 +194,38 @@ func (m Model[I]) cursorWindow() (int, []string) {
 
 	if m.cursor < 2 {
 		items := m.filtered[0:min(m.count, itemCount)]
-		return m.cursor, getTitles(items)
+		return m.cursor, getTitles(items, m.titleWidth())
 	}
 
 	if m.cursor > itemCount-1 {
 		items := m.filtered[max(0, itemCount-m.count-1):itemCount]
 		lastItem := len(items) - 1
-		return lastItem, getTitles(items)
+		return lastItem, getTitles(items, m.titleWidth())
 	}
 
 	first := m.cursor - 1
 	last := min(m.cursor+m.count-1, itemCount)
 	items := m.filtered[first:last]
 
-	return 1, getTitles(items)
+	return 1, getTitles(items, m.titleWidth())
 
 }
 
+// Moves the cursor to the first shown item that matches, returns false if
+// none of them do
+func (m Model[I]) Focus(match func(item I) bool) (Model[I], bool) {
+	for i, item := range m.filtered {
+		if match(item) {
+			m.cursor = i
+			return m, true
+		}
+	}
+
+	return m, false
+}
+
 func (m Model[I]) applyFilter() Model[I] {
-	if m.search == "" {
+	if m.search == "" || !m.filter {
 		m.filtered = m.items
 		return m
 	}
@@ -260,50 +297,33 @@ func (m Model[I]) Update(msg tea.Msg) (Model[I], tea.Cmd) {
 		m.width += msg.Adjust
 
 	case tea.KeyMsg:
-		str := msg.String()
-		if m.searching {
-			switch str {
+		switch {
+		case keymap.Matches(msg, m.searching, m.keys.Up):
+			m = m.cursorUp()
 
-			case "up":
-				m = m.cursorUp()
+		case keymap.Matches(msg, m.searching, m.keys.Down):
+			m = m.cursorDown()
 
-			case "down":
-				m = m.cursorDown()
+		case keymap.Matches(msg, m.searching, m.keys.Select):
+			return m, m.selectedMsg()
 
-			case "esc":
-				m.searching = false
+		case keymap.Matches(msg, m.searching, m.keys.CloseSearch):
+			m.searching = false
 
-			case "enter":
-				return m, m.selectedMsg()
+		case keymap.Matches(msg, m.searching, m.keys.Search):
+			m.searching = true
 
-			case "backspace":
-				if m.search != "" {
-					m.search = m.search[0 : len(m.search)-1]
-					m = m.applyFilter()
-				}
-
-			default:
-				if len(str) == 1 {
-					m.search += str
-					m = m.applyFilter()
-				}
+		case m.searching && msg.Type == tea.KeyBackspace:
+			if m.search != "" {
+				m.search = m.search[0 : len(m.search)-1]
+				m = m.applyFilter()
 			}
-		} else {
-			switch str {
-			case "up", "k":
-				m = m.cursorUp()
-
-			case "down", "j":
-				m = m.cursorDown()
-
-			case " ", "enter":
-				return m, m.selectedMsg()
-
-			case "esc":
-				m.searching = false
 
-			case "/":
-				m.searching = true
+		case m.searching:
+			str := msg.String()
+			if len(str) == 1 {
+				m.search += str
+				m = m.applyFilter()
 			}
 		}
 	}
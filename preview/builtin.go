@@ -0,0 +1,130 @@
+package preview
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"unicode/utf8"
+
+	"github.com/sftsrv/tri/humanize"
+	"github.com/sftsrv/tri/theme"
+)
+
+// Only this many bytes of a file are ever read by the built-in preview
+const headSize = 64 * 1024
+
+// Binary files are dumped up to this many bytes since hex output is ~4x larger
+const dumpSize = 4 * 1024
+
+// Files larger than this are read by the built-in preview even when bat is
+// installed, since older versions of bat read past the lines they highlight
+const highlightSize = 16 * 1024 * 1024
+
+// Number of bytes checked when deciding if a file is binary, same as git
+const sniffSize = 8000
+
+func readHead(path string, size int) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	head := make([]byte, size)
+	n, err := io.ReadFull(f, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, err
+	}
+
+	return head[:n], nil
+}
+
+// Content is considered binary if it has a NUL byte or is mostly made up of
+// control characters and invalid UTF-8
+func isBinary(head []byte) bool {
+	if bytes.IndexByte(head, 0) >= 0 {
+		return true
+	}
+
+	total := 0
+	suspicious := 0
+	for len(head) > 0 {
+		r, size := utf8.DecodeRune(head)
+		head = head[size:]
+		total++
+
+		// a rune cut off at the end of the head is not an indication of anything
+		if r == utf8.RuneError && size == 1 && len(head) < utf8.UTFMax {
+			continue
+		}
+
+		if r == utf8.RuneError && size == 1 {
+			suspicious++
+			continue
+		}
+
+		if r < 0x20 && !bytes.ContainsRune([]byte("\n\r\t\f\b\x1b"), r) {
+			suspicious++
+		}
+	}
+
+	return total > 0 && suspicious*10 > total
+}
+
+func isLarge(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.Size() > highlightSize
+}
+
+func sniff(path string) bool {
+	head, err := readHead(path, sniffSize)
+	if err != nil {
+		return false
+	}
+
+	return isBinary(head)
+}
+
+// Previews a file without an external command, reading at most `headSize`
+// bytes and showing a hex dump for binary content
+func builtin(path string, t theme.Theme) PreviewResultMsg {
+	info, err := os.Stat(path)
+	if err != nil {
+		return PreviewResultMsg{path, true, fmt.Sprintf("ERROR reading %s: %s", path, err.Error())}
+	}
+
+	if info.IsDir() {
+		return PreviewResultMsg{path, false, t.Faded.Render(fmt.Sprintf("directory  %s", info.Mode()))}
+	}
+
+	head, err := readHead(path, headSize)
+	if err != nil {
+		return PreviewResultMsg{path, true, fmt.Sprintf("ERROR reading %s: %s", path, err.Error())}
+	}
+
+	if isBinary(head) {
+		return PreviewResultMsg{path, false, hexView(info, head, t)}
+	}
+
+	content := string(head)
+	if info.Size() > int64(len(head)) {
+		content += "\n" + t.Faded.Render(fmt.Sprintf("... showing first %s of %s", humanize.Bytes(int64(len(head))), humanize.Bytes(info.Size())))
+	}
+
+	return PreviewResultMsg{path, false, content}
+}
+
+func hexView(info os.FileInfo, head []byte, t theme.Theme) string {
+	header := t.Faded.Render(fmt.Sprintf("binary  %s  %s", humanize.Bytes(info.Size()), info.Mode()))
+
+	dump := head[:min(len(head), dumpSize)]
+	content := header + "\n\n" + hex.Dump(dump)
+
+	if info.Size() > int64(len(dump)) {
+		content += t.Faded.Render(fmt.Sprintf("... showing first %s", humanize.Bytes(int64(len(dump)))))
+	}
+
+	return content
+}
@@ -14,12 +14,14 @@ import (
 type Model struct {
 	cmd      string
 	pattern  string
+	source   func(path string) string
 	path     string
 	ready    bool
 	width    int
 	height   int
 	active   *exec.Cmd
 	viewport viewport.Model
+	theme    theme.Theme
 }
 
 type PreviewResultMsg struct {
@@ -41,7 +43,18 @@ func New(preview string, pattern string) Model {
 	}
 }
 
-func (m Model) SetPath(path string) (Model, tea.Cmd) {
+func (m Model) Theme(t theme.Theme) Model {
+	m.theme = t
+	return m
+}
+
+// Use a function to create the preview content instead of running a command
+func (m Model) Source(source func(path string) string) Model {
+	m.source = source
+	return m
+}
+
+func (m Model) SetPath(path string, fields map[string]string) (Model, tea.Cmd) {
 	m.path = path
 
 	if m.active != nil && m.active.Process != nil {
@@ -49,7 +62,14 @@ func (m Model) SetPath(path string) (Model, tea.Cmd) {
 	}
 
 	m.viewport.SetContent("Loading Path: " + path)
-	active, cmd := preview(m.cmd, m.pattern, path, m.width)
+	if m.source != nil {
+		m.active = nil
+		return m, func() tea.Msg {
+			return PreviewResultMsg{path, false, m.source(path)}
+		}
+	}
+
+	active, cmd := preview(m.cmd, m.pattern, path, fields, m.width, m.theme)
 
 	m.active = active
 	return m, cmd
@@ -83,14 +103,27 @@ func (m Model) Height(height int) Model {
 	return m
 }
 
-func preview(preview string, pattern string, path string, width int) (*exec.Cmd, tea.Cmd) {
+func preview(preview string, pattern string, path string, fields map[string]string, width int, t theme.Theme) (*exec.Cmd, tea.Cmd) {
 	if path == "" {
 		return nil, nil
 	}
 
-	cmd, err := command.CreateCommand(preview, pattern, path, width)
+	// without a preview command or bat the file is read directly
+	if preview == "" && !command.HasBat() {
+		return nil, func() tea.Msg {
+			return builtin(path, t)
+		}
+	}
+
+	cmd, err := command.CreateCommand(preview, pattern, path, fields, width)
 
 	return cmd, func() tea.Msg {
+		// bat refuses to print binary files so these get a hex dump instead,
+		// and very large files are only read up to the head
+		if preview == "" && (sniff(path) || isLarge(path)) {
+			return builtin(path, t)
+		}
+
 		if err != nil {
 			return PreviewResultMsg{
 				path,
@@ -112,14 +145,24 @@ func preview(preview string, pattern string, path string, width int) (*exec.Cmd,
 	}
 }
 
+// Runs the preview for the path and waits for its content, for use outside
+// of the interactive preview
+func Capture(previewCmd string, pattern string, path string, fields map[string]string, width int, t theme.Theme) string {
+	_, cmd := preview(previewCmd, pattern, path, fields, width, t)
+	if cmd == nil {
+		return ""
+	}
+
+	return cmd().(PreviewResultMsg).content
+}
+
 func (m Model) View() string {
 	return lg.JoinVertical(
 		lg.Center,
-		lg.NewStyle().
+		m.theme.Palette.On(m.theme.Palette.Secondary).
 			Width(m.width).
 			PaddingLeft(1).
 			PaddingRight(1).
-			Background(theme.ColorSecondary).
 			Render(m.path),
 		m.viewport.View(),
 	)
@@ -1,21 +1,166 @@
 package theme
 
-import lg "github.com/charmbracelet/lipgloss"
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	lg "github.com/charmbracelet/lipgloss"
+)
 
 // color reference: https://codehs.com/uploads/7c2481e9158534231fcb3c9b6003d6b3
 
-var ColorBlack = lg.Color("0")
-var ColorWhite = lg.Color("15")
-var ColorFaded = lg.Color("189")
-var ColorPrimary = lg.Color("183")
-var ColorSecondary = lg.Color("105")
-
-var ColorWarn = lg.Color("220")
-var ColorError = lg.Color("160")
-
-var Heading = lg.NewStyle().Bold(true).Foreground(ColorWhite).PaddingLeft(1).PaddingRight(1).Background(ColorPrimary)
-var Primary = lg.NewStyle().Foreground(ColorPrimary)
-var Faded = lg.NewStyle().Foreground(ColorFaded)
-var Secondary = lg.NewStyle().Foreground(ColorSecondary)
-var Warn = lg.NewStyle().Foreground(ColorWarn)
-var Alert = lg.NewStyle().Bold(true).PaddingLeft(1).PaddingRight(1).Background(ColorError)
+// Colors used throughout the ui, an empty color means none is used
+type Palette struct {
+	Primary   lg.Color `toml:"primary"`
+	Secondary lg.Color `toml:"secondary"`
+	Faded     lg.Color `toml:"faded"`
+	// text shown on top of the other colors, such as in headings
+	Text lg.Color `toml:"text"`
+
+	Warn    lg.Color `toml:"warn"`
+	Error   lg.Color `toml:"error"`
+	Success lg.Color `toml:"success"`
+	Info    lg.Color `toml:"info"`
+}
+
+const (
+	Auto         = "auto"
+	Dark         = "dark"
+	Light        = "light"
+	HighContrast = "high-contrast"
+	Mono         = "mono"
+)
+
+var Presets = map[string]Palette{
+	Dark: {
+		Primary:   "183",
+		Secondary: "105",
+		Faded:     "189",
+		Text:      "15",
+		Warn:      "220",
+		Error:     "160",
+		Success:   "114",
+		Info:      "111",
+	},
+	Light: {
+		Primary:   "97",
+		Secondary: "61",
+		Faded:     "243",
+		Text:      "15",
+		Warn:      "130",
+		Error:     "160",
+		Success:   "28",
+		Info:      "25",
+	},
+	// faded text uses the terminal's own color so it's readable on any
+	// background
+	HighContrast: {
+		Primary:   "13",
+		Secondary: "12",
+		Faded:     "",
+		Text:      "0",
+		Warn:      "11",
+		Error:     "9",
+		Success:   "10",
+		Info:      "14",
+	},
+	Mono: {},
+}
+
+var Names = []string{Auto, Dark, Light, HighContrast, Mono}
+
+// Styles made from a palette
+type Theme struct {
+	Palette Palette
+
+	Heading   lg.Style
+	Primary   lg.Style
+	Secondary lg.Style
+	Faded     lg.Style
+	Warn      lg.Style
+	Error     lg.Style
+	Success   lg.Style
+	Info      lg.Style
+	Alert     lg.Style
+	// items that have just changed
+	Highlight lg.Style
+}
+
+func fg(color lg.Color) lg.Style {
+	if color == "" {
+		return lg.NewStyle()
+	}
+
+	return lg.NewStyle().Foreground(color)
+}
+
+// Text on the background color, without colors the text is reversed instead
+func (p Palette) On(background lg.Color) lg.Style {
+	if background == "" {
+		return lg.NewStyle().Reverse(true)
+	}
+
+	return fg(p.Text).Background(background)
+}
+
+func New(p Palette) Theme {
+	return Theme{
+		Palette:   p,
+		Heading:   p.On(p.Primary).Bold(true).PaddingLeft(1).PaddingRight(1),
+		Primary:   fg(p.Primary),
+		Secondary: fg(p.Secondary),
+		Faded:     fg(p.Faded),
+		Warn:      fg(p.Warn),
+		Error:     fg(p.Error),
+		Success:   fg(p.Success),
+		Info:      fg(p.Info),
+		Alert:     p.On(p.Error).Bold(true).PaddingLeft(1).PaddingRight(1),
+		Highlight: p.On(p.Success).Bold(true),
+	}
+}
+
+// The dark or light preset depending on the terminal's background
+func detect() Palette {
+	if lg.HasDarkBackground() {
+		return Presets[Dark]
+	}
+
+	return Presets[Light]
+}
+
+// Loads a preset by name or a TOML file of colors. Colors missing from the
+// file are taken from the preset that suits the terminal. NO_COLOR turns off
+// colors whatever the theme
+func Load(name string) (Theme, error) {
+	if os.Getenv("NO_COLOR") != "" {
+		return New(Presets[Mono]), nil
+	}
+
+	if name == "" || name == Auto {
+		return New(detect()), nil
+	}
+
+	preset, ok := Presets[name]
+	if ok {
+		return New(preset), nil
+	}
+
+	p := detect()
+	meta, err := toml.DecodeFile(name, &p)
+	if os.IsNotExist(err) {
+		return Theme{}, fmt.Errorf("unknown theme %q, expected a file or one of: %s", name, strings.Join(Names, ", "))
+	}
+
+	if err != nil {
+		return Theme{}, fmt.Errorf("could not read theme %s: %w", name, err)
+	}
+
+	undecoded := meta.Undecoded()
+	if len(undecoded) > 0 {
+		return Theme{}, fmt.Errorf("unknown color %q in theme %s", undecoded[0].String(), name)
+	}
+
+	return New(p), nil
+}
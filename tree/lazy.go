@@ -0,0 +1,67 @@
+package tree
+
+// Whether a folder's children have been read, trees built from input are
+// always loaded
+type load int
+
+const (
+	loaded load = iota
+	unloaded
+	loading
+)
+
+const LOADING_MARKER = "…"
+
+// Marks the tree as a folder whose children will be read once it is expanded
+func (t *Tree) Defer() {
+	t.load = unloaded
+	t.Expanded = false
+}
+
+func (t *Tree) IsLoading() bool {
+	return t.load == loading
+}
+
+// Marks an unread tree as loading, returns false if it is already loading or
+// has been loaded
+func (t *Tree) Load() bool {
+	if t.load != unloaded {
+		return false
+	}
+
+	t.load = loading
+	return true
+}
+
+// Marks the tree as loaded once its children have been added
+func (t *Tree) Loaded() {
+	t.load = loaded
+}
+
+// Collects the expanded folders that are shown but have not been read yet,
+// marking them as loading so that they are only returned once
+func (t *Tree) Pending() []*Tree {
+	if t.load == unloaded && t.Expanded {
+		t.load = loading
+		return []*Tree{t}
+	}
+
+	if !t.Expanded {
+		return nil
+	}
+
+	pending := []*Tree{}
+	for _, child := range t.Children {
+		pending = append(pending, child.Pending()...)
+	}
+
+	return pending
+}
+
+func (s *Item) loadingMarker() string {
+	if s.tree.load != loading {
+		return ""
+	}
+
+	return s.opts.Theme.Faded.Render(LOADING_MARKER)
+}
@@ -0,0 +1,127 @@
+package tree
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	lg "github.com/charmbracelet/lipgloss"
+	"github.com/sftsrv/tri/humanize"
+)
+
+type stat struct {
+	loaded   bool
+	size     int64
+	modified time.Time
+}
+
+func (t *Tree) loadStat() stat {
+	if !t.stat.loaded {
+		t.stat.loaded = true
+
+		info, err := os.Stat(t.Path)
+		if err == nil {
+			t.stat.modified = info.ModTime()
+			if !info.IsDir() {
+				t.stat.size = info.Size()
+			}
+		}
+	}
+
+	return t.stat
+}
+
+// Number of files under the tree, a file counts itself
+func (t *Tree) Files() int {
+	if len(t.Children) == 0 {
+		// folders that have not been read yet are not known to have any
+		if t.load != loaded {
+			return 0
+		}
+
+		return 1
+	}
+
+	count := 0
+	for _, child := range t.Children {
+		count += child.Files()
+	}
+
+	return count
+}
+
+// Total size in bytes of the files under the tree that exist on disk. The
+// size of each file is only read once
+func (t *Tree) Size() int64 {
+	if len(t.Children) == 0 {
+		return t.loadStat().size
+	}
+
+	var size int64
+	for _, child := range t.Children {
+		size += child.Size()
+	}
+
+	return size
+}
+
+// Latest modification time of the files under the tree that exist on disk
+func (t *Tree) Modified() time.Time {
+	if len(t.Children) == 0 {
+		return t.loadStat().modified
+	}
+
+	var modified time.Time
+	for _, child := range t.Children {
+		if childModified := child.Modified(); childModified.After(modified) {
+			modified = childModified
+		}
+	}
+
+	return modified
+}
+
+func columnWidths(items []*Item, columns []string) []int {
+	widths := make([]int, len(columns))
+
+	for _, item := range items {
+		for i, column := range columns {
+			widths[i] = max(widths[i], lg.Width(item.tree.Fields[column]))
+		}
+	}
+
+	return widths
+}
+
+// Columns and metrics for the item, meant to be shown right-aligned next to it
+func (s *Item) Aside() string {
+	metrics := []string{}
+
+	for i, column := range s.opts.Columns {
+		value := s.tree.Fields[column]
+		metrics = append(metrics, value+strings.Repeat(" ", s.widths[i]-lg.Width(value)))
+	}
+
+	if s.opts.Metrics.Count {
+		count := ""
+		if s.kind == folder {
+			count = fmt.Sprintf("%d files", s.tree.Files())
+			if s.tree.Files() == 1 {
+				count = "1 file"
+			}
+		}
+
+		metrics = append(metrics, fmt.Sprintf("%10s", count))
+	}
+
+	if s.opts.Metrics.Size {
+		metrics = append(metrics, fmt.Sprintf("%10s", humanize.Bytes(s.tree.Size())))
+	}
+
+	if len(metrics) == 0 {
+		return ""
+	}
+
+	return s.opts.Theme.Faded.Render(strings.Join(metrics, " "))
+}
@@ -0,0 +1,65 @@
+package tree
+
+// Removes empty and `.` segments and resolves `..` where possible. Absolute
+// paths get a root segment named after their leading separator, e.g. `/`.
+// Paths with nothing left, like `.` or `./`, have no segments since they
+// refer to the top of the tree, such as a folder that is being walked
+func clean(parts Parts, seps []string) (Parts, []string) {
+	absolute := len(parts) > 1 && parts[0] == ""
+
+	cleanParts := Parts{}
+	cleanSeps := []string{}
+
+	for i, part := range parts {
+		last := len(cleanParts) - 1
+
+		switch {
+		case part == "" || part == ".":
+			continue
+
+		case part == ".." && last >= 0 && cleanParts[last] != "..":
+			cleanParts = cleanParts[:last]
+			cleanSeps = cleanSeps[:last]
+
+		// there is nothing above the root
+		case part == ".." && absolute:
+			continue
+
+		default:
+			cleanParts = append(cleanParts, part)
+			cleanSeps = append(cleanSeps, seps[i])
+		}
+	}
+
+	if absolute {
+		// the root holds the separator so the next segment doesn't need one
+		if len(cleanSeps) > 0 {
+			cleanSeps[0] = ""
+		}
+
+		cleanParts = append(Parts{seps[1]}, cleanParts...)
+		cleanSeps = append([]string{""}, cleanSeps...)
+	}
+
+	if len(cleanParts) == 0 {
+		return cleanParts, cleanSeps
+	}
+
+	cleanSeps[0] = ""
+
+	return cleanParts, cleanSeps
+}
+
+// Moves the root down to the deepest folder that contains every path
+func stripPrefix(tree *Tree) *Tree {
+	for len(tree.Children) == 1 {
+		child := tree.Children[tree.order[0]]
+		if len(child.Children) == 0 {
+			break
+		}
+
+		tree = child
+	}
+
+	return tree
+}
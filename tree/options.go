@@ -0,0 +1,55 @@
+package tree
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sftsrv/tri/theme"
+)
+
+// Additional information shown alongside each item
+type Metrics struct {
+	Count bool
+	Size  bool
+}
+
+// Options that control how a tree is turned into items
+type Options struct {
+	Metrics Metrics
+	Sort    Sort
+	// Fields to show as columns next to each item
+	Columns []string
+	Theme   theme.Theme
+}
+
+// Options that control how entries are turned into a tree
+type BuildOptions struct {
+	Separator Separator
+	// Clean up paths so that `./a//b/` and `a/b` are the same
+	Normalize bool
+	// Start the tree at the deepest folder shared by all paths
+	StripPrefix bool
+	// Paths that this returns false for are left out of the tree
+	Keep func(path string) bool
+	// New folders are collapsed below this depth, 0 expands everything
+	Depth int
+}
+
+// Parses a comma separated list of metrics, e.g. `count,size`
+func ParseMetrics(str string) (Metrics, error) {
+	metrics := Metrics{}
+
+	for _, name := range strings.Split(str, ",") {
+		switch strings.TrimSpace(name) {
+		case "":
+		case "count":
+			metrics.Count = true
+		case "size":
+			metrics.Size = true
+		default:
+			return metrics, fmt.Errorf("unknown metric %q, expected count or size", name)
+		}
+	}
+
+	return metrics, nil
+}
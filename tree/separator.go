@@ -0,0 +1,86 @@
+package tree
+
+import "regexp"
+
+// Splits paths into segments. The separator text before each segment is kept
+// so that paths can be rejoined exactly as they were input
+type Separator struct {
+	re      *regexp.Regexp
+	windows bool
+}
+
+var DefaultSeparator = NewSeparator(SEP)
+
+// Windows paths can use either slash and may start with a drive letter or
+// a UNC server name, e.g. `C:\Users` or `\\server\share`
+var WindowsSeparator = Separator{
+	re:      regexp.MustCompile(`[\\/]`),
+	windows: true,
+}
+
+var windowsVolume = regexp.MustCompile(`^(\\\\[^\\/]+|[A-Za-z]:)`)
+
+func NewSeparator(sep string) Separator {
+	return Separator{re: regexp.MustCompile(regexp.QuoteMeta(sep))}
+}
+
+func NewSeparatorRegexp(re string) (Separator, error) {
+	compiled, err := regexp.Compile(re)
+	return Separator{re: compiled}, err
+}
+
+// Returns the segments of the path along with the separator that came
+// before each segment, the first of which is always empty
+func (s Separator) Split(path string) (parts Parts, seps []string) {
+	rest := path
+	parts = Parts{}
+	seps = []string{""}
+
+	if s.windows {
+		volume := windowsVolume.FindString(path)
+		if volume != "" {
+			parts = append(parts, volume)
+			rest = path[len(volume):]
+
+			loc := s.re.FindStringIndex(rest)
+			sep := ""
+			if loc != nil && loc[0] == 0 {
+				sep = rest[:loc[1]]
+				rest = rest[loc[1]:]
+			}
+
+			if rest == "" {
+				return parts, seps
+			}
+
+			seps = append(seps, sep)
+		}
+	}
+
+	start := 0
+	for _, loc := range s.re.FindAllStringIndex(rest, -1) {
+		// an empty match would otherwise split between every character
+		if loc[0] == loc[1] {
+			continue
+		}
+
+		parts = append(parts, rest[start:loc[0]])
+		seps = append(seps, rest[loc[0]:loc[1]])
+		start = loc[1]
+	}
+
+	parts = append(parts, rest[start:])
+
+	return parts, seps
+}
+
+// Joins the parts as they were split, keeping their original separators
+func join(parts Parts, seps []string) string {
+	result := ""
+
+	for i, part := range parts {
+		result += seps[i] + part
+	}
+
+	return result
+}
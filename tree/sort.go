@@ -0,0 +1,184 @@
+package tree
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type SortMode int
+
+const (
+	SortName SortMode = iota
+	SortNatural
+	SortNoCase
+	SortSize
+	SortModified
+	SortInput
+)
+
+var sortModeNames = []string{"name", "natural", "nocase", "size", "mtime", "input"}
+
+func (m SortMode) String() string {
+	return sortModeNames[m]
+}
+
+type Sort struct {
+	Mode         SortMode
+	FoldersFirst bool
+}
+
+func (s Sort) String() string {
+	if s.FoldersFirst {
+		return "folders," + s.Mode.String()
+	}
+
+	return s.Mode.String()
+}
+
+// Cycles to the next sort mode, keeping folders first if set
+func (s Sort) Next() Sort {
+	s.Mode = (s.Mode + 1) % SortMode(len(sortModeNames))
+	return s
+}
+
+// Parses a comma separated sort order, e.g. `folders,natural`
+func ParseSort(str string) (Sort, error) {
+	sort := Sort{}
+
+	for _, name := range strings.Split(str, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		if name == "folders" {
+			sort.FoldersFirst = true
+			continue
+		}
+
+		index := slices.Index(sortModeNames, name)
+		if index < 0 {
+			return sort, fmt.Errorf("unknown sort %q, expected folders or one of %s", name, strings.Join(sortModeNames, ", "))
+		}
+
+		sort.Mode = SortMode(index)
+	}
+
+	return sort, nil
+}
+
+func isDigit(b byte) bool {
+	return '0' <= b && b <= '9'
+}
+
+// Compares strings treating runs of digits as numbers so that `file2` comes
+// before `file10`
+func naturalCompare(a string, b string) int {
+	for a != "" && b != "" {
+		if isDigit(a[0]) && isDigit(b[0]) {
+			i, j := 0, 0
+			for i < len(a) && isDigit(a[i]) {
+				i++
+			}
+			for j < len(b) && isDigit(b[j]) {
+				j++
+			}
+
+			numA := strings.TrimLeft(a[:i], "0")
+			numB := strings.TrimLeft(b[:j], "0")
+
+			if len(numA) != len(numB) {
+				return len(numA) - len(numB)
+			}
+
+			if c := strings.Compare(numA, numB); c != 0 {
+				return c
+			}
+
+			a, b = a[i:], b[j:]
+			continue
+		}
+
+		ra, sizeA := utf8.DecodeRuneInString(a)
+		rb, sizeB := utf8.DecodeRuneInString(b)
+
+		ra, rb = unicode.ToLower(ra), unicode.ToLower(rb)
+		if ra != rb {
+			return int(ra) - int(rb)
+		}
+
+		a, b = a[sizeA:], b[sizeB:]
+	}
+
+	return len(a) - len(b)
+}
+
+func compareChildren(tree *Tree, sort Sort, a string, b string) int {
+	childA, childB := tree.Children[a], tree.Children[b]
+
+	if sort.FoldersFirst {
+		folderA, folderB := len(childA.Children) > 0, len(childB.Children) > 0
+		if folderA != folderB {
+			if folderA {
+				return -1
+			}
+			return 1
+		}
+	}
+
+	switch sort.Mode {
+	case SortNatural:
+		if c := naturalCompare(a, b); c != 0 {
+			return c
+		}
+
+	case SortNoCase:
+		if c := strings.Compare(strings.ToLower(a), strings.ToLower(b)); c != 0 {
+			return c
+		}
+
+	// largest first
+	case SortSize:
+		sizeA, sizeB := childA.Size(), childB.Size()
+		if sizeA != sizeB {
+			if sizeA > sizeB {
+				return -1
+			}
+			return 1
+		}
+
+	// most recent first
+	case SortModified:
+		if c := childB.Modified().Compare(childA.Modified()); c != 0 {
+			return c
+		}
+
+	// keys are already in input order so they are left as-is
+	case SortInput:
+		return 0
+	}
+
+	return strings.Compare(a, b)
+}
+
+func sortedKeys(tree *Tree, sort Sort) []string {
+	keys := slices.Clone(tree.order)
+
+	if sort.Mode == SortInput && !sort.FoldersFirst {
+		return keys
+	}
+
+	slices.SortStableFunc(keys, func(a string, b string) int {
+		return compareChildren(tree, sort, a, b)
+	})
+
+	return keys
+}
+
+// Keys of the tree's children in the given order
+func (t *Tree) Keys(sort Sort) []string {
+	return sortedKeys(t, sort)
+}
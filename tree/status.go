@@ -0,0 +1,90 @@
+package tree
+
+import (
+	"fmt"
+	"strings"
+
+	lg "github.com/charmbracelet/lipgloss"
+	"github.com/sftsrv/tri/theme"
+)
+
+// Version control state of a file, set by a decoration layer such as `git`
+type Status int
+
+const (
+	Unchanged Status = iota
+	Added
+	Modified
+	Deleted
+	Renamed
+	Untracked
+)
+
+// Order in which statuses are listed when summarizing a folder
+var statuses = []Status{Added, Modified, Deleted, Renamed, Untracked}
+
+func (s Status) Marker() string {
+	switch s {
+	case Added:
+		return "A"
+	case Modified:
+		return "M"
+	case Deleted:
+		return "D"
+	case Renamed:
+		return "R"
+	case Untracked:
+		return "?"
+	}
+
+	return ""
+}
+
+func (s Status) style(t theme.Theme) lg.Style {
+	switch s {
+	case Added:
+		return t.Success
+	case Modified:
+		return t.Warn
+	case Deleted:
+		return t.Error
+	case Renamed:
+		return t.Info
+	}
+
+	return t.Faded
+}
+
+func (t *Tree) countStatuses(counts map[Status]int) {
+	if len(t.Children) == 0 && t.Status != Unchanged {
+		counts[t.Status]++
+	}
+
+	for _, child := range t.Children {
+		child.countStatuses(counts)
+	}
+}
+
+// Files show their own status while folders show a count of each status of
+// their descendants, e.g. `2M 1A`
+func (s *Item) status() string {
+	if s.kind == file {
+		if s.tree.Status == Unchanged {
+			return ""
+		}
+
+		return s.tree.Status.style(s.opts.Theme).Render(s.tree.Status.Marker())
+	}
+
+	counts := map[Status]int{}
+	s.tree.countStatuses(counts)
+
+	summary := []string{}
+	for _, status := range statuses {
+		if counts[status] > 0 {
+			summary = append(summary, status.style(s.opts.Theme).Render(fmt.Sprintf("%d%s", counts[status], status.Marker())))
+		}
+	}
+
+	return strings.Join(summary, " ")
+}
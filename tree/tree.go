@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"slices"
 	"strings"
+
+	lg "github.com/charmbracelet/lipgloss"
 )
 
 type Parts = []string
@@ -20,6 +22,9 @@ type Item struct {
 	name  string
 	kind  kind
 	tree  *Tree
+	opts  Options
+	// width of each of the Options.Columns, shared by all items so they line up
+	widths []int
 }
 
 const ICON_FILE = "\uea7b"
@@ -43,6 +48,50 @@ func (t *Tree) ExpandAll() {
 	}
 }
 
+// Only expands folders up to the given depth, with the tree itself at 0
+func (t *Tree) ExpandDepth(depth int) {
+	t.Expanded = depth > 0
+	for _, child := range t.Children {
+		child.ExpandDepth(depth - 1)
+	}
+}
+
+// Expands the next level of collapsed folders under the item, or the item
+// itself if it is collapsed
+func (s *Item) ExpandLevel() {
+	s.tree.ExpandLevel()
+}
+
+func (t *Tree) ExpandLevel() {
+	level := []*Tree{t}
+
+	for len(level) > 0 {
+		collapsed := false
+		next := []*Tree{}
+
+		for _, subtree := range level {
+			if len(subtree.Children) == 0 && subtree.load == loaded {
+				continue
+			}
+
+			if !subtree.Expanded {
+				subtree.Expanded = true
+				collapsed = true
+			}
+
+			for _, child := range subtree.Children {
+				next = append(next, child)
+			}
+		}
+
+		if collapsed {
+			return
+		}
+
+		level = next
+	}
+}
+
 func (s *Item) Collapse() {
 	s.tree.Expanded = false
 }
@@ -66,6 +115,20 @@ func (s *Item) GetPath() string {
 	return s.tree.Path
 }
 
+func (s *Item) GetFields() map[string]string {
+	return s.tree.Fields
+}
+
+// The original input line for the item, falls back to the path for items
+// that were not part of the input such as intermediate folders
+func (s *Item) GetLine() string {
+	if s.tree.Line != "" {
+		return s.tree.Line
+	}
+
+	return s.tree.Path
+}
+
 func (s *Item) icon() string {
 	if s.kind == file {
 		return ICON_FILE
@@ -79,25 +142,148 @@ func (s *Item) icon() string {
 }
 
 func (t *Tree) Flatten() {
-	for childKey, child := range t.Children {
+	order := []string{}
+
+	for _, childKey := range t.order {
+		child := t.Children[childKey]
 		child.Flatten()
+
 		if len(child.Children) != 1 {
+			order = append(order, childKey)
 			continue
 		}
 
-		for grandChildKey, grandChild := range child.Children {
-			grandChild.Flatten()
+		grandChildKey := child.order[0]
+		grandChild := child.Children[grandChildKey]
+		newKey := childKey + grandChild.sep + grandChildKey
 
-			newKey := strings.Join([]string{childKey, grandChildKey}, SEP)
-			t.Children[newKey] = grandChild
+		grandChild.flat = &flattened{
+			folder: child,
+			key:    childKey,
+			sep:    grandChild.sep,
+			next:   grandChild.flat,
 		}
 
+		// the flattened child is now preceded by its parent's separator
+		grandChild.sep = child.sep
+
 		delete(t.Children, childKey)
+		t.Children[newKey] = grandChild
+
+		// the flattened path takes the place of the original child
+		order = append(order, newKey)
+	}
+
+	t.order = order
+}
+
+// Undoes Flatten so that paths can be added and removed by their segments,
+// the tree can be flattened again afterwards
+func (t *Tree) Unflatten() {
+	for i, key := range t.order {
+		child := t.Children[key]
+
+		for child.flat != nil {
+			f := child.flat
+			child.sep, child.flat = f.sep, f.next
+
+			delete(t.Children, key)
+			t.Children[f.key] = f.folder
+			t.order[i] = f.key
+
+			child, key = f.folder, f.key
+		}
+
+		child.Unflatten()
+	}
+}
+
+// Updates the tree to hold exactly the given entries, such as when the input
+// is reloaded. Paths that are still there keep their state, the lines and
+// fields of each path are taken from its first entry
+func (t *Tree) Update(entries []Entry, build BuildOptions) {
+	keep := map[*Tree]bool{}
+
+	for _, entry := range entries {
+		subtree := t.Add(entry, build)
+		if subtree == nil || keep[subtree] {
+			continue
+		}
+
+		keep[subtree] = true
+		subtree.Line = entry.Line
+		subtree.Fields = entry.Fields
+	}
+
+	t.prune(keep)
+}
+
+// Removes the subtrees that are neither kept or above one that is, returns
+// whether anything under the tree was kept
+func (t *Tree) prune(keep map[*Tree]bool) bool {
+	kept := keep[t]
+
+	for _, key := range t.order {
+		if t.Children[key].prune(keep) {
+			kept = true
+			continue
+		}
+
+		delete(t.Children, key)
 	}
+
+	t.order = slices.DeleteFunc(t.order, func(key string) bool {
+		_, ok := t.Children[key]
+		return !ok
+	})
+
+	return kept
+}
+
+// Names can contain newlines when read with NUL delimiters, these would
+// otherwise break the item over multiple lines
+var controlReplacer = strings.NewReplacer("\n", "\\n", "\r", "\\r", "\t", "\\t")
+
+func (s *Item) Name() string {
+	return s.name
+}
+
+func (s *Item) Level() int {
+	return s.level
+}
+
+// The item's name along with its status, without any indentation or icon
+func (s *Item) Label() string {
+	return s.LabelWith("")
+}
+
+// The label with a suffix added to the name, before the status of a folder's
+// files, such as the `/` that some exports end folders with
+func (s *Item) LabelWith(suffix string) string {
+	name := controlReplacer.Replace(s.name) + suffix
+	if s.tree.highlighted {
+		name = s.opts.Theme.Highlight.Render(name)
+	} else if s.kind == file && s.tree.Status != Unchanged {
+		name = s.tree.Status.style(s.opts.Theme).Render(name)
+	}
+
+	status := s.status()
+	if status != "" {
+		name += " " + status
+	}
+
+	return name
 }
 
 func (s *Item) Render() string {
-	return fmt.Sprintf("%s %s %s", strings.Repeat(INDENT, s.level), s.icon(), s.name)
+	line := fmt.Sprintf("%s %s %s", strings.Repeat(INDENT, s.level), s.icon(), s.Label())
+
+	marker := s.loadingMarker()
+	if marker != "" {
+		line += " " + marker
+	}
+
+	return line
 }
 
 func (s *Item) Search() string {
@@ -105,13 +291,57 @@ func (s *Item) Search() string {
 }
 
 type Tree struct {
-	Path     string
+	Path string
+	// The input line that the path was taken from, if any
+	Line string
+	// Extra values that came with the input, such as from a JSON object
+	Fields   map[string]string
 	Expanded bool
+	Status   Status
 	Children map[string]*Tree
+
+	// keys of Children in the order they were first seen in the input
+	order []string
+	// separator that came before this tree's segment in its path
+	sep  string
+	stat stat
+	load load
+	// recently changed on disk
+	highlighted bool
+	// the folder that Flatten merged into this tree's key
+	flat *flattened
+}
+
+// A folder that was merged into its only child's key, kept so that the
+// flattening can be undone
+type flattened struct {
+	folder *Tree
+	key    string
+	sep    string
+	next   *flattened
+}
+
+// Makes the tree stand out, such as after it changes on disk
+func (t *Tree) Highlight(highlighted bool) {
+	t.highlighted = highlighted
+}
+
+// Marks the tree as changed on disk so its stats are read again, and
+// highlights it until that is cleared
+func (t *Tree) Changed() {
+	t.stat = stat{}
+	t.highlighted = true
 }
 
 func (t *Tree) Search() []string {
 	paths := []string{t.Path}
+	if t.Line != "" && t.Line != t.Path {
+		paths = append(paths, t.Line)
+	}
+
+	for _, value := range t.Fields {
+		paths = append(paths, value)
+	}
 
 	for _, subtree := range t.Children {
 		paths = append(paths, subtree.Search()...)
@@ -120,79 +350,165 @@ func (t *Tree) Search() []string {
 	return paths
 }
 
-func newTree(parts Parts) Tree {
+func newTree(parts Parts, seps []string) Tree {
 	return Tree{
-		Path:     strings.Join(parts, SEP),
+		Path:     join(parts, seps),
+		sep:      seps[len(seps)-1],
 		Expanded: true,
 		Children: map[string]*Tree{},
 	}
 }
 
-func partsToTreeRec(current Parts, paths []Parts, depth int) *Tree {
-	subtrees := map[string][]Parts{}
-
-	for _, parts := range paths {
-		if len(parts) <= depth {
-			continue
+// Adds the entry to the tree, creating any missing subtrees along the way.
+// Returns the subtree for the entry
+func (t *Tree) insert(parts Parts, seps []string, depth int, entry Entry, build BuildOptions) *Tree {
+	if len(parts) <= depth {
+		if t.Line == "" {
+			t.Line = entry.Line
+			t.Fields = entry.Fields
 		}
 
-		segment := parts[depth]
+		return t
+	}
 
-		// depth == 0 handles cases where the tree starts with a SEP
-		if segment == "" && depth != 0 {
-			continue
-		}
+	segment := parts[depth]
 
-		subtrees[segment] = append(subtrees[segment], parts)
+	// depth == 0 handles cases where the tree starts with a SEP, otherwise
+	// a trailing SEP refers to the tree itself
+	if segment == "" && depth != 0 {
+		return t
 	}
 
-	tree := newTree(current)
-	for label, parts := range subtrees {
-		tree.Children[label] = partsToTreeRec(
-			append(current, label), parts, depth+1)
+	child, ok := t.Children[segment]
+	if !ok {
+		subtree := newTree(parts[:depth+1], seps[:depth+1])
+		subtree.Expanded = build.Depth == 0 || depth+1 < build.Depth
+
+		child = &subtree
+		t.Children[segment] = child
+		t.order = append(t.order, segment)
 	}
 
-	return &tree
+	return child.insert(parts, seps, depth+1, entry, build)
 }
 
-func pathsToParts(paths []string) []Parts {
-	slices.Sort(paths)
+// A line of input along with the key that places it in the tree
+type Entry struct {
+	Key    string
+	Line   string
+	Fields map[string]string
+	// Segments of the key if it has already been split, in which case the
+	// separator is not used
+	Parts Parts
+}
 
-	result := [][]string{}
+// Splits the entry into the segments that place it in the tree, false if the
+// entry is skipped
+func (build BuildOptions) split(entry Entry) (Parts, []string, bool) {
+	parts, seps := entry.Parts, make([]string, len(entry.Parts))
+	for i := 1; i < len(seps); i++ {
+		seps[i] = SEP
+	}
 
-	for _, path := range paths {
-		result = append(result, strings.Split(path, SEP))
+	if parts != nil {
+		return parts, seps, true
 	}
 
-	return result
+	if build.Normalize && entry.Key == "" {
+		return nil, nil, false
+	}
+
+	parts, seps = build.Separator.Split(entry.Key)
+
+	if build.Normalize {
+		parts, seps = clean(parts, seps)
+	}
+
+	if build.Keep != nil && !build.Keep(join(parts, seps)) {
+		return nil, nil, false
+	}
+
+	return parts, seps, true
+}
+
+// Adds an entry to an existing tree, so that trees can be built as entries
+// are streamed in. Returns the subtree for the entry, or nil if it was skipped
+func (t *Tree) Add(entry Entry, build BuildOptions) *Tree {
+	parts, seps, ok := build.split(entry)
+	if !ok {
+		return nil
+	}
+
+	return t.insert(parts, seps, 0, entry, build)
 }
 
-func PathsToTree(paths []string) *Tree {
-	parts := pathsToParts(paths)
-	return partsToTreeRec(Parts{}, parts, 0)
+// Removes the subtree for the key along with everything under it, returns
+// false if it was not in the tree
+func (t *Tree) Remove(key string, build BuildOptions) bool {
+	parts, _, ok := build.split(Entry{Key: key})
+	if !ok || len(parts) == 0 {
+		return false
+	}
+
+	parent := t
+	for _, part := range parts[:len(parts)-1] {
+		parent = parent.Children[part]
+		if parent == nil {
+			return false
+		}
+	}
+
+	last := parts[len(parts)-1]
+	if _, ok := parent.Children[last]; !ok {
+		return false
+	}
+
+	delete(parent.Children, last)
+	parent.order = slices.DeleteFunc(parent.order, func(key string) bool {
+		return key == last
+	})
+
+	return true
 }
 
-func sortedKeys[T any](items map[string]T) []string {
-	keys := []string{}
+// An empty tree that entries can be added to
+func New() *Tree {
+	tree := newTree(Parts{}, []string{""})
+	return &tree
+}
+
+func EntriesToTree(entries []Entry, build BuildOptions) *Tree {
+	tree := New()
+
+	for _, entry := range entries {
+		tree.Add(entry, build)
+	}
 
-	for key := range items {
-		keys = append(keys, key)
+	if build.StripPrefix {
+		return stripPrefix(tree)
 	}
 
-	slices.Sort(keys)
+	return tree
+}
+
+func PathsToTree(paths []string, build BuildOptions) *Tree {
+	entries := []Entry{}
+	for _, path := range paths {
+		entries = append(entries, Entry{Key: path, Line: path})
+	}
 
-	return keys
+	return EntriesToTree(entries, build)
 }
 
-func toItemsRec(tree *Tree, level int) []*Item {
-	roots := sortedKeys(tree.Children)
+func toItemsRec(tree *Tree, level int, opts Options) []*Item {
+	roots := sortedKeys(tree, opts.Sort)
 
 	lines := []*Item{}
 	for _, root := range roots {
 		children := tree.Children[root]
 
 		kind := file
-		if len(children.Children) > 0 {
+		if len(children.Children) > 0 || children.load != loaded {
 			kind = folder
 		}
 
@@ -201,12 +517,13 @@ func toItemsRec(tree *Tree, level int) []*Item {
 			name:  root,
 			kind:  kind,
 			tree:  children,
+			opts:  opts,
 		}
 
 		lines = append(lines, item)
 
 		if item.tree.Expanded {
-			childLines := toItemsRec(children, level+1)
+			childLines := toItemsRec(children, level+1, opts)
 			lines = append(lines, childLines...)
 		}
 
@@ -215,17 +532,48 @@ func toItemsRec(tree *Tree, level int) []*Item {
 	return lines
 }
 
-func ToItems(tree *Tree) []*Item {
-	return toItemsRec(tree, 0)
+func ToItems(tree *Tree, opts Options) []*Item {
+	items := toItemsRec(tree, 0, opts)
+
+	widths := columnWidths(items, opts.Columns)
+	for _, item := range items {
+		item.widths = widths
+	}
+
+	return items
 }
 
-func Render(tree *Tree) string {
+// Joins the rendered line for each item, with their asides lined up after the
+// longest line
+func AlignAsides(items []*Item, lines []string) string {
 	result := ""
-	items := ToItems(tree)
 
-	for _, item := range items {
-		result += item.Render() + "\n"
+	width := 0
+	for _, line := range lines {
+		width = max(width, lg.Width(line))
+	}
+
+	for i, item := range items {
+		line := lines[i]
+
+		aside := item.Aside()
+		if aside != "" {
+			line += strings.Repeat(" ", width-lg.Width(line)+2) + aside
+		}
+
+		result += strings.TrimRight(line, " ") + "\n"
 	}
 
 	return result
 }
+
+func Render(tree *Tree, opts Options) string {
+	items := ToItems(tree, opts)
+
+	lines := []string{}
+	for _, item := range items {
+		lines = append(lines, item.Render())
+	}
+
+	return AlignAsides(items, lines)
+}
@@ -0,0 +1,120 @@
+package ui
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/sftsrv/tri/command"
+	"github.com/sftsrv/tri/keymap"
+)
+
+const (
+	ActionExecute       = "execute"
+	ActionExecuteSilent = "execute-silent"
+	ActionReload        = "reload"
+)
+
+var Actions = []string{ActionExecute, ActionExecuteSilent, ActionReload}
+
+// A key that runs a command, such as `ctrl-e:execute(nvim $)`
+type Binding struct {
+	Key    string
+	Action string
+	// References to the hovered item are replaced like in the preview command,
+	// for reload this is the source command instead, which uses `$query`
+	Command string
+}
+
+func (b Binding) String() string {
+	if b.Command == "" {
+		return b.Key + ":" + b.Action
+	}
+
+	return fmt.Sprintf("%s:%s(%s)", b.Key, b.Action, b.Command)
+}
+
+var bindingRe = regexp.MustCompile(`^([^:]+):([a-z-]+)(?:\((.*)\))?$`)
+
+func ParseBinding(str string) (Binding, error) {
+	match := bindingRe.FindStringSubmatch(strings.TrimSpace(str))
+	if match == nil {
+		return Binding{}, fmt.Errorf("invalid binding %q, expected key:action(command)", str)
+	}
+
+	binding := Binding{
+		Key:     keymap.NormalizeKey(match[1]),
+		Action:  match[2],
+		Command: match[3],
+	}
+
+	switch binding.Action {
+	case ActionExecute, ActionExecuteSilent:
+		if binding.Command == "" {
+			return binding, fmt.Errorf("binding %q needs a command to %s", str, binding.Action)
+		}
+
+	// reload without a command runs --source again
+	case ActionReload:
+
+	default:
+		return binding, fmt.Errorf("unknown action %q in binding %q, expected one of: %s", binding.Action, str, strings.Join(Actions, ", "))
+	}
+
+	return binding, nil
+}
+
+type executedMsg struct {
+	err error
+}
+
+func (m Model) runBinding(binding Binding) (Model, tea.Cmd) {
+	if binding.Action == ActionReload {
+		source := binding.Command
+		if source == "" {
+			source = m.source
+		}
+
+		return m.startReload(source)
+	}
+
+	if m.hovered == nil {
+		return m, nil
+	}
+
+	cmd, err := command.Action(binding.Command, m.pattern, m.hovered.GetLine(), m.hovered.GetFields())
+	if err != nil {
+		m.message = m.options.Theme.Alert.Render(err.Error())
+		return m, nil
+	}
+
+	done := func(err error) tea.Msg {
+		return executedMsg{err: err}
+	}
+
+	if binding.Action == ActionExecuteSilent {
+		return m, func() tea.Msg {
+			return done(cmd.Run())
+		}
+	}
+
+	// the program gives up the terminal until the command exits
+	return m, tea.ExecProcess(cmd, done)
+}
+
+// The command may have changed the hovered file so its preview is refreshed
+func (m Model) executed(msg executedMsg) (Model, tea.Cmd) {
+	if msg.err != nil {
+		m.message = m.options.Theme.Alert.Render("command failed: " + msg.err.Error())
+	}
+
+	if m.hovered == nil || (!m.hovered.IsFile() && !m.previewFolders) {
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.preview, cmd = m.preview.SetPath(m.hovered.GetLine(), m.hovered.GetFields())
+
+	return m, cmd
+}
@@ -0,0 +1,198 @@
+package ui
+
+import (
+	"path/filepath"
+	"sync"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/sftsrv/tri/tree"
+	"github.com/sftsrv/tri/walk"
+)
+
+// How many folders are read at once in the background while searching
+const searchBatch = 256
+
+type loadedMsg struct {
+	folder  *tree.Tree
+	dir     string
+	files   []string
+	folders []string
+	err     error
+}
+
+// Folders read in the background so that the search can match their paths
+type searchLoadedMsg struct {
+	loaded []loadedMsg
+}
+
+// Folders are added without the include patterns since those are meant for
+// files, and a folder is read before it is known whether any of them match
+func folderBuild(build tree.BuildOptions, opts walk.Options) tree.BuildOptions {
+	build.Keep = opts.KeepFolder
+	return build
+}
+
+// Adds each root as a folder that is read once the ui starts, the current
+// folder is read straight into the top of the tree
+func deferRoots(t *tree.Tree, roots []string, build tree.BuildOptions) {
+	for _, root := range roots {
+		folder := t
+		if root != "." {
+			folder = t.Add(tree.Entry{Key: root, Line: root}, build)
+		}
+
+		if folder == nil {
+			continue
+		}
+
+		folder.Line = root
+		folder.Defer()
+		folder.Expanded = true
+	}
+}
+
+func listFolder(folder *tree.Tree, opts walk.Options) loadedMsg {
+	dir := folder.Line
+	if dir == "" {
+		dir = folder.Path
+	}
+
+	files, folders, err := walk.List(dir, opts)
+	return loadedMsg{folder: folder, dir: filepath.Clean(dir), files: files, folders: folders, err: err}
+}
+
+func loadFolder(folder *tree.Tree, opts walk.Options) tea.Cmd {
+	return func() tea.Msg {
+		return listFolder(folder, opts)
+	}
+}
+
+func loadFolders(folders []*tree.Tree, opts walk.Options) tea.Cmd {
+	return func() tea.Msg {
+		loaded := make([]loadedMsg, len(folders))
+
+		var wait sync.WaitGroup
+		for i, folder := range folders {
+			wait.Add(1)
+			go func() {
+				defer wait.Done()
+				loaded[i] = listFolder(folder, opts)
+			}()
+		}
+
+		wait.Wait()
+		return searchLoadedMsg{loaded: loaded}
+	}
+}
+
+// Starts reading the folders that have been expanded but not loaded yet
+func (m Model) loadPending() tea.Cmd {
+	if !m.lazy {
+		return nil
+	}
+
+	cmds := []tea.Cmd{}
+	for _, folder := range m.tree.Pending() {
+		cmds = append(cmds, loadFolder(folder, m.walk))
+	}
+
+	return tea.Batch(cmds...)
+}
+
+// While searching, folders that have not been expanded are read a batch at a
+// time, nearest first, so that the search can match the paths inside of them.
+// They stay collapsed and are shown through the folders that contain them
+func (m Model) loadSearch() (Model, tea.Cmd) {
+	if !m.lazy || m.searchLoading || m.pathPicker.GetSearch() == "" {
+		return m, nil
+	}
+
+	batch := []*tree.Tree{}
+	for len(batch) < searchBatch && len(m.unread) > 0 {
+		folder := m.unread[0]
+		m.unread = m.unread[1:]
+
+		// folders that have been expanded are already loaded or loading
+		if folder.Load() {
+			batch = append(batch, folder)
+		}
+	}
+
+	if len(batch) == 0 {
+		return m, nil
+	}
+
+	m.searchLoading = true
+	return m, loadFolders(batch, m.walk)
+}
+
+// Leaves an empty folder to be read once it is expanded or searched into, as
+// long as it is within --max-depth
+func (m Model) deferFolder(folder *tree.Tree, path string) Model {
+	path = filepath.Clean(path)
+	depth := m.depths[filepath.Dir(path)] + 1
+
+	if len(folder.Children) > 0 || !m.walk.Reads(depth) {
+		return m
+	}
+
+	folder.Defer()
+	m.depths[path] = depth
+	m.unread = append(m.unread, folder)
+
+	return m
+}
+
+// Adds the folder's children and watches it for changes now that it is shown
+func (m Model) addLoaded(msg loadedMsg) Model {
+	for _, path := range msg.files {
+		m.tree.Add(tree.Entry{Key: path, Line: path}, m.build)
+	}
+
+	for _, path := range msg.folders {
+		folder := m.tree.Add(tree.Entry{Key: path, Line: path}, folderBuild(m.build, m.walk))
+		if folder != nil {
+			m = m.deferFolder(folder, path)
+		}
+	}
+
+	msg.folder.Loaded()
+
+	if m.watcher != nil && msg.err == nil {
+		m.watcher.Add(msg.dir)
+	}
+
+	return m
+}
+
+func (m Model) addFolder(msg loadedMsg) (Model, tea.Cmd) {
+	m = m.addLoaded(msg)
+
+	if msg.err != nil {
+		m.message = m.options.Theme.Alert.Render("could not read folder: " + msg.err.Error())
+	}
+
+	m, _ = m.refreshItems()
+
+	var cmd tea.Cmd
+	m.pathPicker, cmd = m.pathPicker.Update(msg)
+
+	return m, tea.Batch(cmd, m.loadPending())
+}
+
+// Errors are not shown for folders read in the background since they were
+// never asked for, such as folders that can't be read when searching /
+func (m Model) addSearchFolders(msg searchLoadedMsg) (Model, tea.Cmd) {
+	for _, loaded := range msg.loaded {
+		m = m.addLoaded(loaded)
+	}
+
+	m.searchLoading = false
+	m, _ = m.refreshItems()
+
+	var cmd tea.Cmd
+	m.pathPicker, cmd = m.pathPicker.Update(msg)
+
+	m, search := m.loadSearch()
+	return m, tea.Batch(cmd, m.loadPending(), search)
+}
@@ -0,0 +1,154 @@
+package ui
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/sftsrv/tri/command"
+	"github.com/sftsrv/tri/input"
+	"github.com/sftsrv/tri/tree"
+)
+
+// Runs the source with the query and sends each line of its output until it
+// exits or is cancelled. Returns why it failed, along with the last thing it
+// wrote to stderr
+func runLive(ctx context.Context, template string, query string, out chan<- string) error {
+	ctx, stop := context.WithCancel(ctx)
+	defer stop()
+
+	cmd := command.Source(ctx, template, query)
+
+	stderr := &bytes.Buffer{}
+	cmd.Stderr = stderr
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	scanner := input.NewScanner(stdout)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		select {
+		case out <- line:
+		case <-ctx.Done():
+			// cancelling kills the source's process group so the rest of the
+			// pipeline stops along with the shell
+			cmd.Wait()
+			return nil
+		}
+	}
+
+	// the source is stopped since nothing reads the rest of its output
+	if err := scanner.Err(); err != nil {
+		stop()
+		cmd.Wait()
+		return err
+	}
+
+	err = cmd.Wait()
+	if err == nil || ctx.Err() != nil {
+		return nil
+	}
+
+	// grep and rg exit with 1 when nothing matches, which isn't a failure
+	var exit *exec.ExitError
+	if errors.As(err, &exit) && exit.ExitCode() == 1 && stderr.Len() == 0 {
+		return nil
+	}
+
+	lines := strings.Split(strings.TrimSpace(stderr.String()), "\n")
+	if last := lines[len(lines)-1]; last != "" {
+		return fmt.Errorf("%w: %s", err, last)
+	}
+
+	return err
+}
+
+// Cancels the previous run of the live source and starts it again with the
+// current search. The tree is replaced once the first results come in so
+// that the old results are shown until then
+func (m Model) startLive() (Model, tea.Cmd) {
+	if m.cancelLive != nil {
+		m.cancelLive()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream := make(chan string)
+	failed := make(chan error, 1)
+	done := make(chan struct{})
+
+	// the error is sent before the stream is closed so that it is there once
+	// the stream is done
+	go func() {
+		failed <- runLive(ctx, m.live, m.query, stream)
+		close(stream)
+		close(done)
+	}()
+
+	m.cancelLive = cancel
+	m.liveFailed = failed
+	m.liveDone = done
+	m.stream = stream
+	m.replaceTree = true
+	m.pathPicker = m.pathPicker.Title(m.title())
+
+	return m, readStream(stream)
+}
+
+// Stops the current run of the live source and waits for it to exit, so
+// that nothing is left running once the program exits
+func (m Model) stopLive() {
+	if m.cancelLive == nil {
+		return
+	}
+
+	m.cancelLive()
+	<-m.liveDone
+}
+
+// Shows why the live source failed, once all of its output has been read
+func (m Model) liveError() Model {
+	select {
+	case err := <-m.liveFailed:
+		if err != nil {
+			m.message = m.options.Theme.Alert.Render("live source failed: " + err.Error())
+		}
+
+	default:
+	}
+
+	return m
+}
+
+// Starts the live source again if the search has changed
+func (m Model) updateQuery() (Model, tea.Cmd) {
+	if m.live == "" || m.pathPicker.GetSearch() == m.query {
+		return m, nil
+	}
+
+	m.query = m.pathPicker.GetSearch()
+	return m.startLive()
+}
+
+func (m Model) takeLiveResults() Model {
+	if m.replaceTree {
+		m.tree = tree.New()
+		m.replaceTree = false
+	}
+
+	return m
+}
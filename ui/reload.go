@@ -0,0 +1,86 @@
+package ui
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/sftsrv/tri/tree"
+)
+
+type reloadedMsg struct {
+	// results of older reloads that finish late are dropped
+	generation int
+	entries    []tree.Entry
+	// sets the git status read along with the entries, if there is one
+	decorate func(t *tree.Tree)
+	err      error
+}
+
+// Re-reads the input from the source command using the current search as
+// the query
+func (m Model) startReload(source string) (Model, tea.Cmd) {
+	if m.reload == nil || source == "" {
+		return m, nil
+	}
+
+	m.reloading = true
+	m.reloads++
+	m.pathPicker = m.pathPicker.Title(m.title())
+
+	reload := m.reload
+	decorate := m.decorate
+	query := m.pathPicker.GetSearch()
+	generation := m.reloads
+
+	return m, func() tea.Msg {
+		entries, err := reload(source, query)
+		if err != nil {
+			return reloadedMsg{generation: generation, err: err}
+		}
+
+		msg := reloadedMsg{generation: generation, entries: entries}
+		if decorate != nil {
+			msg.decorate = decorate()
+		}
+
+		return msg
+	}
+}
+
+// Adds and removes paths so that the tree matches the reloaded entries,
+// keeping the expanded folders and the cursor on the same paths
+func (m Model) applyReload(msg reloadedMsg) (Model, tea.Cmd) {
+	if msg.generation != m.reloads {
+		return m, nil
+	}
+
+	m.reloading = false
+	m.pathPicker = m.pathPicker.Title(m.title())
+
+	if msg.err != nil {
+		m.message = m.options.Theme.Alert.Render("reload failed: " + msg.err.Error())
+		return m, nil
+	}
+
+	// paths are matched by their segments, which flattened keys hide
+	flattened := m.flattened()
+	if flattened {
+		m.tree.Unflatten()
+	}
+
+	m.tree.Update(msg.entries, m.build)
+
+	if flattened {
+		m.tree.Flatten()
+	}
+
+	if msg.decorate != nil {
+		msg.decorate(m.tree)
+	}
+
+	m, _ = m.refreshItems()
+
+	// the hovered item still belongs to the old tree until it is hovered again
+	var cmd tea.Cmd
+	m.pathPicker, cmd = m.pathPicker.Update(msg)
+
+	return m, cmd
+}
@@ -0,0 +1,92 @@
+package ui
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/sftsrv/tri/tree"
+)
+
+// Paths are collected for this long before being added to the tree so that
+// the items are not rebuilt for every single path
+const streamInterval = 50 * time.Millisecond
+
+type streamMsg struct {
+	// the stream that the paths came from, since a live search replaces it
+	stream <-chan string
+	paths  []string
+	done   bool
+}
+
+func readStream(stream <-chan string) tea.Cmd {
+	return func() tea.Msg {
+		path, ok := <-stream
+		if !ok {
+			return streamMsg{stream: stream, done: true}
+		}
+
+		paths := []string{path}
+		timeout := time.After(streamInterval)
+
+		for {
+			select {
+			case path, ok := <-stream:
+				if !ok {
+					return streamMsg{stream: stream, paths: paths, done: true}
+				}
+
+				paths = append(paths, path)
+
+			case <-timeout:
+				return streamMsg{stream: stream, paths: paths}
+			}
+		}
+	}
+}
+
+func (m Model) addPaths(msg streamMsg) (Model, tea.Cmd) {
+	if msg.stream != m.stream {
+		return m, nil
+	}
+
+	m = m.takeLiveResults()
+
+	for _, path := range msg.paths {
+		m.tree.Add(tree.Entry{Key: path, Line: path}, m.build)
+	}
+
+	next := readStream(m.stream)
+	if msg.done {
+		m.stream = nil
+		next = nil
+		m = m.liveError()
+
+		// flattening changes the keys of the tree so it can only happen once
+		// everything is in it
+		if m.flat {
+			m.tree.Flatten()
+		}
+	}
+
+	m.pathPicker = m.pathPicker.Title(m.title())
+	m, _ = m.refreshItems()
+
+	var cmd tea.Cmd
+	m.pathPicker, cmd = m.pathPicker.Update(msg)
+
+	return m, tea.Batch(cmd, next)
+}
+
+// Whether the tree has been flattened, streamed trees are only flattened once
+// they are complete and lazy trees never are
+func (m Model) flattened() bool {
+	return m.flat && m.stream == nil && !m.lazy
+}
+
+func (m Model) title() string {
+	if m.stream != nil || m.reloading {
+		return "Items (loading)"
+	}
+
+	return "Items"
+}
@@ -4,12 +4,16 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
 	lg "github.com/charmbracelet/lipgloss"
+	"github.com/sftsrv/tri/export"
+	"github.com/sftsrv/tri/keymap"
 	"github.com/sftsrv/tri/picker"
 	"github.com/sftsrv/tri/preview"
-	"github.com/sftsrv/tri/theme"
 	"github.com/sftsrv/tri/tree"
+	"github.com/sftsrv/tri/walk"
+	"github.com/sftsrv/tri/watch"
 )
 
 type window struct {
@@ -19,20 +23,113 @@ type window struct {
 
 type Path string
 
+// Settings for an interactive session
+type Config struct {
+	Preview string
+	Pattern string
+	Flat    bool
+	// Initial depth that folders are expanded to, 0 expands everything
+	Depth   int
+	Options tree.Options
+	// Creates previews instead of the preview command, also used for folders
+	PreviewSource func(line string) string
+	// Terminate the selection with NUL instead of a newline
+	Print0 bool
+	// File that the current tree is written to with the export key
+	ExportFile string
+	Export     export.Config
+	// Paths to add to the tree while running, such as from walking a folder
+	Stream <-chan string
+	Build  tree.BuildOptions
+	// Read folders from the roots as they are expanded instead of up front
+	Lazy  bool
+	Roots []string
+	Walk  walk.Options
+	// Changes on disk that are applied to the tree while running, lazy
+	// folders are added to the watcher as they are read
+	Watch   <-chan watch.Event
+	Watcher *watch.Watcher
+	// Reads the input from a source command with the current search as the
+	// query, Source is used unless a binding gives another command
+	Reload func(source string, query string) ([]tree.Entry, error)
+	Source string
+	// Reads the git status again when the tree is reloaded, returning what
+	// sets it on the paths in the tree. Called outside of the ui so it can
+	// take a while
+	Decorate func() func(t *tree.Tree)
+	// Command that is run again whenever the search changes, with its output
+	// replacing the tree. `$query` is replaced with the search
+	Live string
+	// Keys that run commands on the hovered item
+	Bindings []Binding
+	// Keys for the built in actions, the defaults are used if this is nil
+	KeyMap *keymap.KeyMap
+}
+
 type Model struct {
 	window window
 
-	tree *tree.Tree
+	tree    *tree.Tree
+	options tree.Options
 
 	hovered    *tree.Item
 	selected   *tree.Item
 	pathPicker picker.Model[*tree.Item]
 
-	preview preview.Model
+	preview        preview.Model
+	previewFolders bool
+
+	exportFile string
+	export     export.Config
+
+	// shown in the help bar until the next key press
+	message string
+
+	stream <-chan string
+	build  tree.BuildOptions
+	flat   bool
+
+	lazy bool
+	walk walk.Options
+	// how far below its root each folder is by path, roots are left out
+	depths map[string]int
+	// folders that have not been read, in the order they were found
+	unread        []*tree.Tree
+	searchLoading bool
+
+	watch   <-chan watch.Event
+	watcher *watch.Watcher
+
+	reload    func(source string, query string) ([]tree.Entry, error)
+	source    string
+	reloading bool
+	reloads   int
+	decorate  func() func(t *tree.Tree)
+
+	live        string
+	query       string
+	cancelLive  func()
+	liveFailed  <-chan error
+	liveDone    <-chan struct{}
+	replaceTree bool
+
+	pattern  string
+	bindings []Binding
+	keys     keymap.KeyMap
 }
 
 func (m Model) Init() tea.Cmd {
-	return nil
+	cmds := []tea.Cmd{m.loadPending()}
+
+	if m.stream != nil {
+		cmds = append(cmds, readStream(m.stream))
+	}
+
+	if m.watch != nil {
+		cmds = append(cmds, readWatch(m.watch))
+	}
+
+	return tea.Batch(cmds...)
 }
 
 func (w *window) updateWindowSize(width int, height int) {
@@ -61,8 +158,8 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case picker.HoverMsg[*tree.Item]:
 		m.hovered = msg.Hovered
 
-		if msg.Hovered.IsFile() {
-			preview, c := m.preview.SetPath(msg.Hovered.GetPath())
+		if msg.Hovered.IsFile() || m.previewFolders {
+			preview, c := m.preview.SetPath(msg.Hovered.GetLine(), msg.Hovered.GetFields())
 			m.preview = preview
 
 			return m, c
@@ -73,52 +170,111 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case preview.PreviewReadyMsg:
 		hovered := m.hovered
 		if hovered != nil {
-			preview, c := m.preview.SetPath(hovered.GetPath())
+			preview, c := m.preview.SetPath(hovered.GetLine(), hovered.GetFields())
 
 			m.preview = preview
 			return m, c
 		}
 
+	case streamMsg:
+		return m.addPaths(msg)
+
+	case loadedMsg:
+		return m.addFolder(msg)
+
+	case searchLoadedMsg:
+		return m.addSearchFolders(msg)
+
+	case watchMsg:
+		return m.applyChanges(msg)
+
+	case clearHighlightsMsg:
+		return m.clearHighlights(msg), nil
+
+	case reloadedMsg:
+		return m.applyReload(msg)
+
+	case executedMsg:
+		return m.executed(msg)
+
 	case preview.PreviewResultMsg:
 		m.preview = m.preview.SetContent(msg)
 		return m, nil
 
 	case tea.KeyMsg:
-		str := msg.String()
+		m.message = ""
+		searching := m.pathPicker.IsSearching()
+
+		// while searching, bindings on printable keys are left for typing
+		// into the search
+		for _, binding := range m.bindings {
+			if keymap.Matches(msg, searching, key.NewBinding(key.WithKeys(binding.Key))) {
+				return m.runBinding(binding)
+			}
+		}
 
-		switch str {
-		case "ctrl+c", "q":
+		switch {
+		case keymap.Matches(msg, searching, m.keys.Quit):
 			return m, tea.Quit
 
-		case "left", "h":
-			if m.hovered != nil && (str == "left" || !m.pathPicker.IsSearching()) {
+		case keymap.Matches(msg, searching, m.keys.Reload):
+			if m.live != "" {
+				return m.startLive()
+			}
+
+			if m.source != "" {
+				return m.startReload(m.source)
+			}
+
+		case keymap.Matches(msg, searching, m.keys.Collapse):
+			if m.hovered != nil {
 				m.hovered.Collapse()
-				m.pathPicker, cmd = m.pathPicker.Items(tree.ToItems(m.tree)).Update(msg)
+				m.pathPicker, cmd = m.pathPicker.Items(tree.ToItems(m.tree, m.options)).Update(msg)
 				return m, cmd
 			}
 
-		case "right", "l":
-			if m.hovered != nil && (str == "right" || !m.pathPicker.IsSearching()) {
+		case keymap.Matches(msg, searching, m.keys.Expand):
+			if m.hovered != nil {
 				m.hovered.Expand()
-				m.pathPicker, cmd = m.pathPicker.Items(tree.ToItems(m.tree)).Update(msg)
-				return m, cmd
+				load := m.loadPending()
+				m.pathPicker, cmd = m.pathPicker.Items(tree.ToItems(m.tree, m.options)).Update(msg)
+				return m, tea.Batch(cmd, load)
 			}
 
-		case "]":
-			if !m.pathPicker.IsSearching() {
-				m.tree.ExpandAll()
-				m.pathPicker, cmd = m.pathPicker.Items(tree.ToItems(m.tree)).Update(msg)
-				return m, cmd
+		case keymap.Matches(msg, searching, m.keys.ExpandAll):
+			m.tree.ExpandAll()
+			load := m.loadPending()
+			m.pathPicker, cmd = m.pathPicker.Items(tree.ToItems(m.tree, m.options)).Update(msg)
+			return m, tea.Batch(cmd, load)
+
+		case keymap.Matches(msg, searching, m.keys.CollapseAll):
+			m.tree.CollapseAll()
+			m.pathPicker, cmd = m.pathPicker.Items(tree.ToItems(m.tree, m.options)).Update(msg)
+			return m, cmd
+
+		case keymap.Matches(msg, searching, m.keys.ExpandLevel):
+			if m.hovered != nil {
+				m.hovered.ExpandLevel()
+				load := m.loadPending()
+				m.pathPicker, cmd = m.pathPicker.Items(tree.ToItems(m.tree, m.options)).Update(msg)
+				return m, tea.Batch(cmd, load)
 			}
 
-		case "[":
-			if !m.pathPicker.IsSearching() {
-				m.tree.CollapseAll()
-				m.pathPicker, cmd = m.pathPicker.Items(tree.ToItems(m.tree)).Update(msg)
-				return m, cmd
-			}
+		case keymap.Matches(msg, searching, m.keys.Sort):
+			m.options.Sort = m.options.Sort.Next()
+			m.pathPicker, cmd = m.pathPicker.Items(tree.ToItems(m.tree, m.options)).Update(msg)
+			return m, cmd
+
+		case keymap.Matches(msg, searching, m.keys.FoldersFirst):
+			m.options.Sort.FoldersFirst = !m.options.Sort.FoldersFirst
+			m.pathPicker, cmd = m.pathPicker.Items(tree.ToItems(m.tree, m.options)).Update(msg)
+			return m, cmd
 
-		case "{":
+		case keymap.Matches(msg, searching, m.keys.Export):
+			m.message = m.exportTree()
+			return m, nil
+
+		case keymap.Matches(msg, searching, m.keys.Shrink):
 			pathPicker, pathPickerCmd := m.pathPicker.Update(picker.ResizeMsg{Adjust: -1})
 			preview, previewCmd := m.preview.Update(preview.ResizeMsg{Adjust: +1})
 
@@ -127,7 +283,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 			return m, tea.Batch(pathPickerCmd, previewCmd)
 
-		case "}":
+		case keymap.Matches(msg, searching, m.keys.Grow):
 			pathPicker, pathPickerCmd := m.pathPicker.Update(picker.ResizeMsg{Adjust: +1})
 			preview, previewCmd := m.preview.Update(preview.ResizeMsg{Adjust: -1})
 
@@ -144,33 +300,51 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	}
 
 	m.pathPicker, cmd = m.pathPicker.Update(msg)
-	return m, cmd
+
+	m, live := m.updateQuery()
+	m, load := m.loadSearch()
+	return m, tea.Batch(cmd, live, load)
+}
+
+// Writes the tree as it is currently shown to the export file
+func (m Model) exportTree() string {
+	if m.exportFile == "" {
+		return m.options.Theme.Warn.Render("set --export-file to export")
+	}
+
+	output, err := export.Render(m.tree, m.options, m.export)
+	if err == nil {
+		err = os.WriteFile(m.exportFile, []byte(output), 0644)
+	}
+
+	if err != nil {
+		return m.options.Theme.Alert.Render("export failed: " + err.Error())
+	}
+
+	return m.options.Theme.Primary.Render("exported to " + m.exportFile)
 }
 
 func helpView(m Model) string {
 	item := func(icon string, title string) string {
 		return lg.JoinHorizontal(
 			lg.Top,
-			theme.Secondary.MarginLeft(2).Render(icon),
-			theme.Faded.MarginRight(2).Render(" ", title),
+			m.options.Theme.Secondary.MarginLeft(2).Render(icon),
+			m.options.Theme.Faded.MarginRight(2).Render(" ", title),
 		)
 	}
 
 	help := ""
-	if m.pathPicker.IsSearching() {
-		help += item("esc", "close search")
-		help += item("↓↑", "navigate")
-		help += item("→", "expand")
-		help += item("←", "collapse")
-		help += item("ctrk+c", "quit")
-	} else {
-		help += item("/", "search")
-		help += item("↓↑/jk", "navigate")
-		help += item("→/l", "expand")
-		help += item("←/h", "collapse")
-		help += item("]/[", "expand/collapse all")
-		help += item("}/{", "resize")
-		help += item("ctrk+c/q", "quit")
+	if m.message != "" {
+		help += lg.NewStyle().MarginLeft(2).MarginRight(2).Render(m.message)
+	}
+
+	for _, h := range m.keys.Help(m.pathPicker.IsSearching()) {
+		desc := h.Desc
+		if desc == m.keys.Sort.Help().Desc {
+			desc += ": " + m.options.Sort.String()
+		}
+
+		help += item(h.Key, desc)
 	}
 
 	return lg.NewStyle().Render(help)
@@ -184,22 +358,75 @@ func (m Model) View() string {
 	)
 }
 
-func initialModel(f *tree.Tree, previewCmd string, previewPattern string) Model {
-	items := tree.ToItems(f)
+func initialModel(f *tree.Tree, config Config) Model {
+	items := tree.ToItems(f, config.Options)
+
+	preview := preview.New(config.Preview, config.Pattern).Theme(config.Options.Theme)
+	if config.PreviewSource != nil {
+		preview = preview.Source(config.PreviewSource)
+	}
+
+	m := Model{
+		tree:           f,
+		options:        config.Options,
+		pathPicker:     picker.New[*tree.Item]().Theme(config.Options.Theme).Items(items),
+		preview:        preview,
+		previewFolders: config.PreviewSource != nil,
+		exportFile:     config.ExportFile,
+		export:         config.Export,
+		stream:         config.Stream,
+		build:          config.Build,
+		flat:           config.Flat,
+		lazy:           config.Lazy,
+		walk:           config.Walk,
+		depths:         map[string]int{},
+		watch:          config.Watch,
+		watcher:        config.Watcher,
+		reload:         config.Reload,
+		source:         config.Source,
+		decorate:       config.Decorate,
+		live:           config.Live,
+		pattern:        config.Pattern,
+		bindings:       config.Bindings,
+	}
+
+	m.build.Depth = config.Depth
 
-	return Model{
-		tree:       f,
-		pathPicker: picker.New[*tree.Item]().Title("Items").Accent(theme.ColorPrimary).Items(items),
-		preview:    preview.New(previewCmd, previewPattern),
+	m.keys = keymap.Default()
+	if config.KeyMap != nil {
+		m.keys = *config.KeyMap
 	}
+
+	m.keys.Reload.SetEnabled(m.source != "")
+	m.pathPicker = m.pathPicker.KeyMap(m.keys)
+
+	// the search is only used as the query for the live source
+	if m.live != "" {
+		m.pathPicker = m.pathPicker.Filter(false)
+		m, _ = m.startLive()
+	}
+
+	m.pathPicker = m.pathPicker.Title(m.title())
+
+	return m
 }
 
-func Run(f *tree.Tree, previewCmd string, previewPattern string, flat bool) {
-	if flat {
+func Run(f *tree.Tree, config Config) {
+	if config.Lazy {
+		deferRoots(f, config.Roots, folderBuild(config.Build, config.Walk))
+	}
+
+	if config.Depth > 0 {
+		f.ExpandDepth(config.Depth)
+	}
+
+	// streamed trees are flattened once they are complete, and lazy trees
+	// can't be since their folders are still being added to
+	if config.Flat && config.Stream == nil && !config.Lazy {
 		f.Flatten()
 	}
 
-	m := initialModel(f, previewCmd, previewPattern)
+	m := initialModel(f, config)
 	p := tea.NewProgram(m,
 		tea.WithAltScreen(),
 		tea.WithMouseCellMotion(),
@@ -211,9 +438,15 @@ func Run(f *tree.Tree, previewCmd string, previewPattern string, flat bool) {
 		os.Exit(1)
 	}
 
+	result.(Model).stopLive()
 	selected := result.(Model).selected
 
 	if selected != nil {
-		fmt.Println(result.(Model).selected.GetPath())
+		terminator := "\n"
+		if config.Print0 {
+			terminator = "\x00"
+		}
+
+		fmt.Print(selected.GetLine() + terminator)
 	}
 }
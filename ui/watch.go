@@ -0,0 +1,136 @@
+package ui
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/sftsrv/tri/tree"
+	"github.com/sftsrv/tri/watch"
+)
+
+// How long changed items stay highlighted
+const highlightDuration = time.Second
+
+type watchMsg struct {
+	events []watch.Event
+}
+
+type clearHighlightsMsg struct {
+	trees []*tree.Tree
+}
+
+// Changes are batched like streamed paths since a build can touch many files
+// at once
+func readWatch(events <-chan watch.Event) tea.Cmd {
+	return func() tea.Msg {
+		batch := []watch.Event{<-events}
+		timeout := time.After(streamInterval)
+
+		for {
+			select {
+			case event := <-events:
+				batch = append(batch, event)
+
+			case <-timeout:
+				return watchMsg{events: batch}
+			}
+		}
+	}
+}
+
+func clearHighlights(trees []*tree.Tree) tea.Cmd {
+	return tea.Tick(highlightDuration, func(time.Time) tea.Msg {
+		return clearHighlightsMsg{trees: trees}
+	})
+}
+
+// Rebuilds the items while keeping the cursor on the hovered path, returns
+// false if the hovered path is no longer shown
+func (m Model) refreshItems() (Model, bool) {
+	m.pathPicker = m.pathPicker.Items(tree.ToItems(m.tree, m.options))
+	if m.hovered == nil {
+		return m, false
+	}
+
+	path := m.hovered.GetPath()
+
+	var found bool
+	m.pathPicker, found = m.pathPicker.Focus(func(item *tree.Item) bool {
+		return item.GetPath() == path
+	})
+
+	return m, found
+}
+
+func (m Model) applyChanges(msg watchMsg) (Model, tea.Cmd) {
+	changed := []*tree.Tree{}
+	hoveredChanged := false
+
+	// paths are added and removed by their segments, which flattened keys
+	// hide, so the tree is flattened again once the changes are in
+	flattened := m.flattened()
+	if flattened {
+		m.tree.Unflatten()
+	}
+
+	for _, event := range msg.events {
+		if event.Op == watch.Removed {
+			m.tree.Remove(event.Path, m.build)
+			continue
+		}
+
+		build := m.build
+		if m.lazy && event.Folder {
+			build = folderBuild(build, m.walk)
+		}
+
+		subtree := m.tree.Add(tree.Entry{Key: event.Path, Line: event.Path}, build)
+		if subtree == nil {
+			continue
+		}
+
+		// created folders are read like the others once they are opened
+		if m.lazy && event.Folder {
+			m = m.deferFolder(subtree, event.Path)
+		}
+
+		subtree.Changed()
+		changed = append(changed, subtree)
+
+		if m.hovered != nil && m.hovered.GetLine() == subtree.Line {
+			hoveredChanged = true
+		}
+	}
+
+	if flattened {
+		m.tree.Flatten()
+	}
+
+	cmds := []tea.Cmd{readWatch(m.watch), clearHighlights(changed)}
+
+	m, found := m.refreshItems()
+
+	switch {
+	// the cursor stays where it was and hovers whatever took the path's place
+	case !found:
+		var cmd tea.Cmd
+		m.pathPicker, cmd = m.pathPicker.Update(msg)
+		cmds = append(cmds, cmd)
+
+	case hoveredChanged:
+		var cmd tea.Cmd
+		m.preview, cmd = m.preview.SetPath(m.hovered.GetLine(), m.hovered.GetFields())
+		cmds = append(cmds, cmd)
+	}
+
+	return m, tea.Batch(cmds...)
+}
+
+func (m Model) clearHighlights(msg clearHighlightsMsg) Model {
+	for _, subtree := range msg.trees {
+		subtree.Highlight(false)
+	}
+
+	m, _ = m.refreshItems()
+	return m
+}
@@ -0,0 +1,170 @@
+package walk
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+type Options struct {
+	Hidden bool
+	// Follow symlinks to folders, each folder is only visited once
+	Follow bool
+	// How many levels below the roots to go, 0 means no limit
+	MaxDepth int
+	// Files that this returns false for are skipped. It is called from many
+	// goroutines at once so it must be safe for concurrent use
+	Keep func(path string) bool
+	// Folders that this returns false for are skipped along with their
+	// contents, with the same rules as Keep
+	KeepFolder func(path string) bool
+}
+
+type walker struct {
+	opts Options
+	out  chan<- string
+	wait sync.WaitGroup
+	// limits how many folders are read at once
+	sem chan struct{}
+
+	lock    sync.Mutex
+	visited map[string]bool
+}
+
+// Only needed when following symlinks since that's the only way to get a cycle
+func (w *walker) visit(path string) bool {
+	real, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return false
+	}
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.visited[real] {
+		return false
+	}
+
+	w.visited[real] = true
+	return true
+}
+
+func isDir(path string, entry os.DirEntry, opts Options) bool {
+	if entry.Type()&os.ModeSymlink == 0 {
+		return entry.IsDir()
+	}
+
+	if !opts.Follow {
+		return false
+	}
+
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// Whether the path is skipped, folders are skipped along with their contents
+func (opts Options) Skips(path string, folder bool) bool {
+	if !opts.Hidden && strings.HasPrefix(filepath.Base(path), ".") {
+		return true
+	}
+
+	keep := opts.Keep
+	if folder {
+		keep = opts.KeepFolder
+	}
+
+	return keep != nil && !keep(path)
+}
+
+// Whether a folder this many levels below a root is read, following MaxDepth
+func (opts Options) Reads(depth int) bool {
+	return opts.MaxDepth <= 0 || depth < opts.MaxDepth
+}
+
+func (w *walker) dir(path string, depth int) {
+	defer w.wait.Done()
+
+	w.sem <- struct{}{}
+	entries, err := os.ReadDir(path)
+	<-w.sem
+
+	if err != nil {
+		return
+	}
+
+	for _, entry := range entries {
+		child := filepath.Join(path, entry.Name())
+		folder := isDir(child, entry, w.opts)
+		if w.opts.Skips(child, folder) {
+			continue
+		}
+
+		w.out <- child
+
+		if !folder {
+			continue
+		}
+
+		if !w.opts.Reads(depth + 1) {
+			continue
+		}
+
+		if w.opts.Follow && !w.visit(child) {
+			continue
+		}
+
+		w.wait.Add(1)
+		go w.dir(child, depth+1)
+	}
+}
+
+// Sends every path under the roots to `out` as they are found, folders are
+// read concurrently so paths are not in any particular order. `out` is
+// closed once everything has been walked
+func Walk(roots []string, opts Options, out chan<- string) {
+	w := &walker{
+		opts:    opts,
+		out:     out,
+		sem:     make(chan struct{}, runtime.NumCPU()*2),
+		visited: map[string]bool{},
+	}
+
+	for _, root := range roots {
+		if opts.Follow {
+			w.visit(root)
+		}
+
+		w.wait.Add(1)
+		go w.dir(root, 0)
+	}
+
+	w.wait.Wait()
+	close(out)
+}
+
+// Reads only the direct children of a folder, split into files and folders,
+// so that folders can be loaded as they are needed
+func List(dir string, opts Options) (files []string, folders []string, err error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, entry := range entries {
+		child := filepath.Join(dir, entry.Name())
+		folder := isDir(child, entry, opts)
+		if opts.Skips(child, folder) {
+			continue
+		}
+
+		if folder {
+			folders = append(folders, child)
+		} else {
+			files = append(files, child)
+		}
+	}
+
+	return files, folders, nil
+}
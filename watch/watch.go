@@ -0,0 +1,158 @@
+package watch
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/sftsrv/tri/walk"
+)
+
+type Op int
+
+const (
+	Created Op = iota
+	Removed
+	Changed
+)
+
+type Event struct {
+	Path string
+	Op   Op
+	// Set for created folders
+	Folder bool
+}
+
+// Watches folders as they are added, since the os only watches a single
+// folder at a time
+type Watcher struct {
+	opts   walk.Options
+	events chan<- Event
+	// whether folders created while watching are watched along with their
+	// contents, otherwise they are left to be added once they are read
+	recursive bool
+	watcher   *fsnotify.Watcher
+}
+
+// Watches a single folder for changes to its direct children
+func (w *Watcher) Add(dir string) error {
+	return w.watcher.Add(dir)
+}
+
+// Watches the folder and every folder under it. When `report` is set the
+// paths that are found are sent as created, for folders that were created
+// along with their contents
+func (w *Watcher) addTree(root string, report bool) {
+	filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+
+		if path != root && w.opts.Skips(path, entry.IsDir()) {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+
+		if report && path != root {
+			w.events <- Event{Path: path, Op: Created, Folder: entry.IsDir()}
+		}
+
+		if entry.IsDir() {
+			w.watcher.Add(path)
+		}
+
+		return nil
+	})
+}
+
+// Watches every folder under the roots, this walks them so it is meant to be
+// run in the background
+func (w *Watcher) AddTrees(roots []string) {
+	for _, root := range roots {
+		w.addTree(root, false)
+	}
+}
+
+func (w *Watcher) isDir(path string) bool {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return false
+	}
+
+	if info.Mode()&os.ModeSymlink != 0 && w.opts.Follow {
+		info, err = os.Stat(path)
+		return err == nil && info.IsDir()
+	}
+
+	return info.IsDir()
+}
+
+func (w *Watcher) handle(event fsnotify.Event) {
+	path := filepath.Clean(event.Name)
+
+	switch {
+	case event.Has(fsnotify.Create):
+		folder := w.isDir(path)
+		if w.opts.Skips(path, folder) {
+			return
+		}
+
+		w.events <- Event{Path: path, Op: Created, Folder: folder}
+
+		if folder && w.recursive {
+			w.addTree(path, true)
+		}
+
+	// renames are followed by a create for the new name
+	// removed paths can't be checked for being a folder, removing a path that
+	// was never added does nothing so only what skips folders is checked
+	case event.Has(fsnotify.Remove), event.Has(fsnotify.Rename):
+		if w.opts.Skips(path, true) {
+			return
+		}
+
+		w.events <- Event{Path: path, Op: Removed}
+
+	case event.Has(fsnotify.Write):
+		if w.opts.Skips(path, false) {
+			return
+		}
+
+		w.events <- Event{Path: path, Op: Changed}
+	}
+}
+
+// Sends changes in the folders that are added to `events` until the program
+// exits. Paths that the options skip are ignored
+func New(opts walk.Options, recursive bool, events chan<- Event) (*Watcher, error) {
+	fsWatcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	w := &Watcher{opts: opts, events: events, recursive: recursive, watcher: fsWatcher}
+
+	go func() {
+		for {
+			select {
+			case event, ok := <-fsWatcher.Events:
+				if !ok {
+					return
+				}
+
+				w.handle(event)
+
+			case _, ok := <-fsWatcher.Errors:
+				if !ok {
+					return
+				}
+			}
+		}
+	}()
+
+	return w, nil
+}